@@ -0,0 +1,47 @@
+package admission_criteria
+
+type DTO struct {
+	ID                uint64 `json:"id"`
+	Name              string `json:"name"`
+	SpontaneousDemand bool   `json:"spontaneous_demand"`
+}
+
+type Form struct {
+	Name string `json:"name" form:"required,max=255"`
+	// SpontaneousDemand marks a criterion meaning that the place takes
+	// walk-ins, as listed by /places/urgent.
+	SpontaneousDemand bool `json:"spontaneous_demand"`
+}
+
+type AdmissionCriterion struct {
+	ID                uint64 `json:"id"`
+	Name              string `json:"name"`
+	SpontaneousDemand bool   `json:"spontaneous_demand"`
+}
+
+type AdmissionCriteria []*AdmissionCriterion
+
+func (r *AdmissionCriterion) ToDto() *DTO {
+	return &DTO{
+		ID:                r.ID,
+		Name:              r.Name,
+		SpontaneousDemand: r.SpontaneousDemand,
+	}
+}
+
+func (rgs AdmissionCriteria) ToDto() []*DTO {
+	dtos := make([]*DTO, len(rgs))
+
+	for i, v := range rgs {
+		dtos[i] = v.ToDto()
+	}
+
+	return dtos
+}
+
+func (f *Form) ToModel() AdmissionCriterion {
+	return AdmissionCriterion{
+		Name:              f.Name,
+		SpontaneousDemand: f.SpontaneousDemand,
+	}
+}
@@ -0,0 +1,82 @@
+package admission_criteria
+
+import (
+	"database/sql"
+
+	pgUtil "cuide/util/pg"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) List() (AdmissionCriteria, error) {
+	admissionCriteria := make([]*AdmissionCriterion, 0)
+
+	rows, err := r.db.Query("SELECT id, nome, demanda_espontanea FROM public.criterios_admissao;")
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var admissionCriterion AdmissionCriterion
+		rows.Scan(&admissionCriterion.ID, &admissionCriterion.Name, &admissionCriterion.SpontaneousDemand)
+
+		admissionCriteria = append(admissionCriteria, &admissionCriterion)
+	}
+
+	return admissionCriteria, nil
+}
+
+func (r *Repository) Create(admissionCriterion *AdmissionCriterion) (*AdmissionCriterion, error) {
+	err := r.db.QueryRow(
+		"INSERT INTO public.criterios_admissao (nome, demanda_espontanea) VALUES ($1, $2) RETURNING id;",
+		admissionCriterion.Name,
+		admissionCriterion.SpontaneousDemand,
+	).Scan(&admissionCriterion.ID)
+	if err != nil {
+		return nil, pgUtil.ConstraintErr(err)
+	}
+
+	return admissionCriterion, nil
+}
+
+func (r *Repository) Read(id uint64) (*AdmissionCriterion, error) {
+	var admissionCriterion AdmissionCriterion
+	err := r.db.QueryRow("SELECT id, nome, demanda_espontanea FROM public.criterios_admissao r WHERE r.id = $1;", id).
+		Scan(&admissionCriterion.ID, &admissionCriterion.Name, &admissionCriterion.SpontaneousDemand)
+	if err != nil {
+		return nil, err
+	}
+
+	return &admissionCriterion, nil
+}
+
+func (r *Repository) Update(admissionCriterion *AdmissionCriterion) (int64, error) {
+	result, err := r.db.Exec(
+		"UPDATE public.criterios_admissao SET nome = $1, demanda_espontanea = $2 WHERE id= $3;",
+		admissionCriterion.Name,
+		admissionCriterion.SpontaneousDemand,
+		admissionCriterion.ID,
+	)
+	if err != nil {
+		return 0, pgUtil.ConstraintErr(err)
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *Repository) Delete(id uint64) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM public.criterios_admissao WHERE id = $1;", id)
+	if err != nil {
+		return 0, pgUtil.ConstraintErr(err)
+	}
+
+	return result.RowsAffected()
+}
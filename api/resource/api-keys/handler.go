@@ -0,0 +1,283 @@
+package api_keys
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog"
+
+	e "cuide/api/resource/common/err"
+	l "cuide/api/resource/common/log"
+	ctxUtil "cuide/util/ctx"
+	validatorUtil "cuide/util/validator"
+)
+
+type API struct {
+	logger     *zerolog.Logger
+	validator  *validator.Validate
+	repository *Repository
+}
+
+func New(logger *zerolog.Logger, validator *validator.Validate, db *sql.DB) *API {
+	return &API{
+		logger:     logger,
+		validator:  validator,
+		repository: NewRepository(db),
+	}
+}
+
+// List godoc
+//
+//	@summary		List API keys
+//	@description	List the API keys of partner systems, revoked ones included. Keys are only shown when issued.
+//	@tags			api-keys
+//	@accept			json
+//	@produce		json
+//	@security		BearerAuth
+//	@success		200	{array}		DTO
+//	@failure		401	{object}	err.Error
+//	@failure		403	{object}	err.Error
+//	@failure		500	{object}	err.Error
+//	@router			/api-keys [get]
+func (a *API) List(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	keys, err := a.repository.List()
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataAccessFailure)
+		return
+	}
+
+	if len(keys) == 0 {
+		fmt.Fprint(w, "[]")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(keys.ToDto()); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespJSONEncodeFailure)
+		return
+	}
+}
+
+// Create godoc
+//
+//	@summary		Issue API key
+//	@description	Issue an API key, sent by partner systems in the X-API-Key header. The key is only shown in this response.
+//	@tags			api-keys
+//	@accept			json
+//	@produce		json
+//	@security		BearerAuth
+//	@param			body	body		Form	true	"API key form"
+//	@success		201		{object}	IssuedDTO
+//	@failure		400		{object}	err.Error
+//	@failure		401		{object}	err.Error
+//	@failure		403		{object}	err.Error
+//	@failure		422		{object}	err.Errors
+//	@failure		500		{object}	err.Error
+//	@router			/api-keys [post]
+func (a *API) Create(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	form := &Form{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.BadRequest(w, e.RespJSONDecodeFailure)
+		return
+	}
+
+	if err := a.validator.Struct(form); err != nil {
+		respBody, err := json.Marshal(validatorUtil.ToErrResponse(err))
+		if err != nil {
+			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+			e.ServerError(w, e.RespJSONEncodeFailure)
+			return
+		}
+
+		e.ValidationErrors(w, respBody)
+		return
+	}
+
+	key, prefix, hash, err := NewKey()
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespAPIKeyIssueFailure)
+		return
+	}
+
+	newKey := form.ToModel()
+	newKey.Prefix = prefix
+	newKey.Hash = hash
+	if userID, err := strconv.ParseUint(ctxUtil.Subject(r.Context()), 10, 64); err == nil {
+		newKey.CreatedBy = &userID
+	}
+
+	apiKey, err := a.repository.Create(newKey)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataInsertFailure)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", apiKey.ID).Msg("new api key issued")
+
+	w.WriteHeader(http.StatusCreated)
+	dto := &IssuedDTO{DTO: *apiKey.ToDto(), Key: key}
+	if err := json.NewEncoder(w).Encode(dto); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		return
+	}
+}
+
+// Read godoc
+//
+//	@summary		Read API key
+//	@description	Read API key
+//	@tags			api-keys
+//	@accept			json
+//	@produce		json
+//	@security		BearerAuth
+//	@param			id	path		string	true	"API key ID"
+//	@success		200	{object}	DTO
+//	@failure		400	{object}	err.Error
+//	@failure		401	{object}	err.Error
+//	@failure		403	{object}	err.Error
+//	@failure		404
+//	@failure		500	{object}	err.Error
+//	@router			/api-keys/{id} [get]
+func (a *API) Read(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		e.BadRequest(w, e.RespInvalidURLParamID)
+		return
+	}
+
+	apiKey, err := a.repository.Read(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataAccessFailure)
+		return
+	}
+
+	dto := apiKey.ToDto()
+	if err := json.NewEncoder(w).Encode(dto); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespJSONEncodeFailure)
+		return
+	}
+}
+
+// Update godoc
+//
+//	@summary		Update API key
+//	@description	Update the name, scopes and rate limit of an API key. Revoked keys can not be updated.
+//	@tags			api-keys
+//	@accept			json
+//	@produce		json
+//	@security		BearerAuth
+//	@param			id		path	string	true	"API key ID"
+//	@param			body	body	Form	true	"API key form"
+//	@success		200
+//	@failure		400	{object}	err.Error
+//	@failure		401	{object}	err.Error
+//	@failure		403	{object}	err.Error
+//	@failure		404
+//	@failure		422	{object}	err.Errors
+//	@failure		500	{object}	err.Error
+//	@router			/api-keys/{id} [put]
+func (a *API) Update(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		e.BadRequest(w, e.RespInvalidURLParamID)
+		return
+	}
+
+	form := &Form{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.BadRequest(w, e.RespJSONDecodeFailure)
+		return
+	}
+
+	if err := a.validator.Struct(form); err != nil {
+		respBody, err := json.Marshal(validatorUtil.ToErrResponse(err))
+		if err != nil {
+			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+			e.ServerError(w, e.RespJSONEncodeFailure)
+			return
+		}
+
+		e.ValidationErrors(w, respBody)
+		return
+	}
+
+	apiKey := form.ToModel()
+	apiKey.ID = id
+
+	rows, err := a.repository.Update(apiKey)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataUpdateFailure)
+		return
+	}
+	if rows == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", id).Msg("api key updated")
+}
+
+// Delete godoc
+//
+//	@summary		Revoke API key
+//	@description	Revoke an API key. Revoked keys are still listed, so that the request logs can be traced back to them.
+//	@tags			api-keys
+//	@accept			json
+//	@produce		json
+//	@security		BearerAuth
+//	@param			id	path	string	true	"API key ID"
+//	@success		200
+//	@failure		400	{object}	err.Error
+//	@failure		401	{object}	err.Error
+//	@failure		403	{object}	err.Error
+//	@failure		404
+//	@failure		500	{object}	err.Error
+//	@router			/api-keys/{id} [delete]
+func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		e.BadRequest(w, e.RespInvalidURLParamID)
+		return
+	}
+
+	rows, err := a.repository.Revoke(id)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataUpdateFailure)
+		return
+	}
+	if rows == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", id).Msg("api key revoked")
+}
@@ -0,0 +1,135 @@
+package api_keys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"slices"
+	"strconv"
+	"time"
+
+	"cuide/util/rbac"
+)
+
+const (
+	// keyPrefix starts every key, so that leaked keys are easy to find.
+	keyPrefix = "cuide_"
+	// shownPrefixLen is the length of the start of a key kept in clear, to
+	// tell keys apart in listings.
+	shownPrefixLen = len(keyPrefix) + 6
+
+	// DefaultRateLimit is the requests per minute allowed to a key issued
+	// without a rate limit.
+	DefaultRateLimit = 60
+)
+
+type DTO struct {
+	ID        uint64       `json:"id"`
+	Name      string       `json:"name"`
+	Prefix    string       `json:"prefix"`
+	Scopes    []rbac.Scope `json:"scopes"`
+	RateLimit int          `json:"rate_limit"`
+	CreatedBy *uint64      `json:"created_by"`
+	CreatedAt time.Time    `json:"created_at"`
+	RevokedAt *time.Time   `json:"revoked_at"`
+}
+
+// IssuedDTO is returned when a key is issued, the only time the key itself
+// is shown.
+type IssuedDTO struct {
+	DTO
+	Key string `json:"key"`
+}
+
+// Form issues or updates a key. RateLimit is in requests per minute and
+// defaults to DefaultRateLimit.
+type Form struct {
+	Name      string       `json:"name"       form:"required,max=255"`
+	Scopes    []rbac.Scope `json:"scopes"     form:"required,min=1,dive,oneof=read write:places"`
+	RateLimit int          `json:"rate_limit" form:"omitempty,min=1,max=100000"`
+}
+
+type APIKey struct {
+	ID        uint64
+	Name      string
+	Prefix    string
+	Hash      []byte
+	Scopes    []rbac.Scope
+	RateLimit int
+	CreatedBy *uint64
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+type APIKeys []*APIKey
+
+// NewKey returns a random key, the prefix kept in clear for it and its hash.
+func NewKey() (key, prefix string, hash []byte, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", nil, err
+	}
+
+	key = keyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:shownPrefixLen], HashKey(key), nil
+}
+
+func HashKey(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+// Subject identifies the key in the request context, apart from the IDs of
+// the users.
+func (k *APIKey) Subject() string {
+	return "api-key:" + strconv.FormatUint(k.ID, 10)
+}
+
+// Principal returns the principal of the requests made with k.
+func (k *APIKey) Principal() *rbac.Principal {
+	return &rbac.Principal{
+		Subject: k.Subject(),
+		Scopes:  k.Scopes,
+	}
+}
+
+func (k *APIKey) ToDto() *DTO {
+	return &DTO{
+		ID:        k.ID,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		RateLimit: k.RateLimit,
+		CreatedBy: k.CreatedBy,
+		CreatedAt: k.CreatedAt,
+		RevokedAt: k.RevokedAt,
+	}
+}
+
+func (ks APIKeys) ToDto() []*DTO {
+	dtos := make([]*DTO, len(ks))
+
+	for i, v := range ks {
+		dtos[i] = v.ToDto()
+	}
+
+	return dtos
+}
+
+func (f *Form) ToModel() *APIKey {
+	k := &APIKey{
+		Name:      f.Name,
+		RateLimit: f.RateLimit,
+	}
+	if k.RateLimit == 0 {
+		k.RateLimit = DefaultRateLimit
+	}
+
+	for _, s := range f.Scopes {
+		if !slices.Contains(k.Scopes, s) {
+			k.Scopes = append(k.Scopes, s)
+		}
+	}
+
+	return k
+}
@@ -0,0 +1,83 @@
+package api_keys
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"slices"
+	"strings"
+	"testing"
+
+	"cuide/util/rbac"
+)
+
+func TestNewKey(t *testing.T) {
+	key, prefix, hash, err := NewKey()
+	if err != nil {
+		t.Fatalf("NewKey: %v", err)
+	}
+
+	if !strings.HasPrefix(key, keyPrefix) {
+		t.Errorf("key %q does not start with %q", key, keyPrefix)
+	}
+	if len(prefix) != shownPrefixLen || !strings.HasPrefix(key, prefix) {
+		t.Errorf("prefix %q is not the first %d bytes of %q", prefix, shownPrefixLen, key)
+	}
+	if !bytes.Equal(hash, HashKey(key)) {
+		t.Error("hash is not HashKey(key)")
+	}
+	if sum := sha256.Sum256([]byte(key)); !bytes.Equal(hash, sum[:]) {
+		t.Error("hash is not the SHA-256 of the key")
+	}
+
+	other, _, otherHash, err := NewKey()
+	if err != nil {
+		t.Fatalf("NewKey: %v", err)
+	}
+	if other == key || bytes.Equal(otherHash, hash) {
+		t.Error("NewKey returned the same key twice")
+	}
+}
+
+func TestHashKey(t *testing.T) {
+	if !bytes.Equal(HashKey("cuide_a"), HashKey("cuide_a")) {
+		t.Error("HashKey is not deterministic")
+	}
+	if bytes.Equal(HashKey("cuide_a"), HashKey("cuide_b")) {
+		t.Error("HashKey is the same for different keys")
+	}
+}
+
+func TestFormToModel(t *testing.T) {
+	f := &Form{
+		Name:   "partner",
+		Scopes: []rbac.Scope{rbac.ScopeRead, rbac.ScopePlacesWrite, rbac.ScopeRead},
+	}
+
+	k := f.ToModel()
+	if k.RateLimit != DefaultRateLimit {
+		t.Errorf("RateLimit = %d, want %d", k.RateLimit, DefaultRateLimit)
+	}
+	if want := []rbac.Scope{rbac.ScopeRead, rbac.ScopePlacesWrite}; !slices.Equal(k.Scopes, want) {
+		t.Errorf("Scopes = %v, want %v", k.Scopes, want)
+	}
+
+	f.RateLimit = 10
+	if k := f.ToModel(); k.RateLimit != 10 {
+		t.Errorf("RateLimit = %d, want 10", k.RateLimit)
+	}
+}
+
+func TestPrincipal(t *testing.T) {
+	k := &APIKey{ID: 7, Scopes: []rbac.Scope{rbac.ScopeRead}}
+
+	p := k.Principal()
+	if p.Subject != "api-key:7" {
+		t.Errorf("Subject = %q, want api-key:7", p.Subject)
+	}
+	if len(p.Assignments) != 0 {
+		t.Errorf("Assignments = %v, want none", p.Assignments)
+	}
+	if p.Has(rbac.PermPlacesWrite) {
+		t.Error("a read key has places:write")
+	}
+}
@@ -0,0 +1,156 @@
+package api_keys
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+
+	"cuide/util/rbac"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+const selectAPIKeys = `SELECT
+	id, nome, prefixo, chave_hash, escopos, limite_por_minuto, usuario_id, created_at, revoked_at
+FROM public.chave_api`
+
+func scanAPIKey(row interface{ Scan(...any) error }) (*APIKey, error) {
+	var (
+		k         APIKey
+		scopes    pq.StringArray
+		createdBy sql.NullInt64
+		revokedAt sql.NullTime
+	)
+	err := row.Scan(
+		&k.ID,
+		&k.Name,
+		&k.Prefix,
+		&k.Hash,
+		&scopes,
+		&k.RateLimit,
+		&createdBy,
+		&k.CreatedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	k.Scopes = make([]rbac.Scope, len(scopes))
+	for i, s := range scopes {
+		k.Scopes[i] = rbac.Scope(s)
+	}
+	if createdBy.Valid {
+		id := uint64(createdBy.Int64)
+		k.CreatedBy = &id
+	}
+	if revokedAt.Valid {
+		k.RevokedAt = &revokedAt.Time
+	}
+
+	return &k, nil
+}
+
+func scopesArray(scopes []rbac.Scope) pq.StringArray {
+	a := make(pq.StringArray, len(scopes))
+	for i, s := range scopes {
+		a[i] = string(s)
+	}
+
+	return a
+}
+
+func (r *Repository) List() (APIKeys, error) {
+	keys := make(APIKeys, 0)
+
+	rows, err := r.db.Query(selectAPIKeys + " ORDER BY id;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
+
+// Create stores k, whose Prefix and Hash come from NewKey.
+func (r *Repository) Create(k *APIKey) (*APIKey, error) {
+	err := r.db.QueryRow(
+		`INSERT INTO public.chave_api
+			(nome, prefixo, chave_hash, escopos, limite_por_minuto, usuario_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at;`,
+		k.Name,
+		k.Prefix,
+		k.Hash,
+		scopesArray(k.Scopes),
+		k.RateLimit,
+		k.CreatedBy,
+	).Scan(&k.ID, &k.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+func (r *Repository) Read(id uint64) (*APIKey, error) {
+	return scanAPIKey(r.db.QueryRow(selectAPIKeys+" WHERE id = $1;", id))
+}
+
+// ReadByKey finds the key that was issued as key, unless it was revoked.
+func (r *Repository) ReadByKey(ctx context.Context, key string) (*APIKey, error) {
+	return scanAPIKey(r.db.QueryRowContext(
+		ctx,
+		selectAPIKeys+" WHERE chave_hash = $1 AND revoked_at IS NULL;",
+		HashKey(key),
+	))
+}
+
+// Update saves the name, scopes and rate limit of k, unless it was revoked.
+func (r *Repository) Update(k *APIKey) (int64, error) {
+	result, err := r.db.Exec(
+		`UPDATE public.chave_api
+		SET nome = $1, escopos = $2, limite_por_minuto = $3
+		WHERE id = $4 AND revoked_at IS NULL;`,
+		k.Name,
+		scopesArray(k.Scopes),
+		k.RateLimit,
+		k.ID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Revoke revokes the key, which is kept so that the logs still point to it.
+func (r *Repository) Revoke(id uint64) (int64, error) {
+	result, err := r.db.Exec(
+		"UPDATE public.chave_api SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL;",
+		id,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
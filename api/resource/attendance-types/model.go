@@ -0,0 +1,40 @@
+package attendance_types
+
+type DTO struct {
+	ID   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+type Form struct {
+	Name string `json:"name" form:"required,max=255"`
+}
+
+type AttendanceType struct {
+	ID   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+type AttendanceTypes []*AttendanceType
+
+func (r *AttendanceType) ToDto() *DTO {
+	return &DTO{
+		ID:   r.ID,
+		Name: r.Name,
+	}
+}
+
+func (rgs AttendanceTypes) ToDto() []*DTO {
+	dtos := make([]*DTO, len(rgs))
+
+	for i, v := range rgs {
+		dtos[i] = v.ToDto()
+	}
+
+	return dtos
+}
+
+func (f *Form) ToModel() AttendanceType {
+	return AttendanceType{
+		Name: f.Name,
+	}
+}
@@ -0,0 +1,78 @@
+package attendance_types
+
+import (
+	"database/sql"
+
+	pgUtil "cuide/util/pg"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) List() (AttendanceTypes, error) {
+	attendanceTypes := make([]*AttendanceType, 0)
+
+	rows, err := r.db.Query("SELECT id, nome FROM public.tipo_atendimento;")
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var attendanceType AttendanceType
+		rows.Scan(&attendanceType.ID, &attendanceType.Name)
+
+		attendanceTypes = append(attendanceTypes, &attendanceType)
+	}
+
+	return attendanceTypes, nil
+}
+
+func (r *Repository) Create(attendanceType *AttendanceType) (*AttendanceType, error) {
+	err := r.db.QueryRow("INSERT INTO public.tipo_atendimento (nome) VALUES ($1) RETURNING id;", attendanceType.Name).
+		Scan(&attendanceType.ID)
+	if err != nil {
+		return nil, pgUtil.ConstraintErr(err)
+	}
+
+	return attendanceType, nil
+}
+
+func (r *Repository) Read(id uint64) (*AttendanceType, error) {
+	var attendanceType AttendanceType
+	err := r.db.QueryRow("SELECT id, nome FROM public.tipo_atendimento r WHERE r.id = $1;", id).
+		Scan(&attendanceType.ID, &attendanceType.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &attendanceType, nil
+}
+
+func (r *Repository) Update(attendanceType *AttendanceType) (int64, error) {
+	result, err := r.db.Exec(
+		"UPDATE public.tipo_atendimento SET nome = $1 WHERE id= $2;",
+		attendanceType.Name,
+		attendanceType.ID,
+	)
+	if err != nil {
+		return 0, pgUtil.ConstraintErr(err)
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *Repository) Delete(id uint64) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM public.tipo_atendimento WHERE id = $1;", id)
+	if err != nil {
+		return 0, pgUtil.ConstraintErr(err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog"
+
+	e "cuide/api/resource/common/err"
+	l "cuide/api/resource/common/log"
+	"cuide/api/resource/users"
+	ctxUtil "cuide/util/ctx"
+	"cuide/util/jwt"
+	validatorUtil "cuide/util/validator"
+)
+
+const tokenTypeBearer = "Bearer"
+
+// dummyPasswordHash is compared against when the email is unknown, so that
+// a login takes as long whether or not the user exists.
+const dummyPasswordHash = "$2a$10$hgSbSrlmkQyN8n.1P5dEle6Su.QfFROS9jU4PCrQ0qRngOqHRutK2"
+
+// Options configure the tokens issued by the API.
+type Options struct {
+	Signer   *jwt.Signer
+	Issuer   string
+	Audience string
+	// AccessTokenTTL is the lifetime of the access tokens, which can not be
+	// revoked, so it should be short.
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+type API struct {
+	logger     *zerolog.Logger
+	validator  *validator.Validate
+	users      *users.Repository
+	repository *Repository
+	options    Options
+}
+
+func New(logger *zerolog.Logger, validator *validator.Validate, db *sql.DB, options Options) *API {
+	return &API{
+		logger:     logger,
+		validator:  validator,
+		users:      users.NewRepository(db),
+		repository: NewRepository(db),
+		options:    options,
+	}
+}
+
+// Login godoc
+//
+//	@summary		Log in
+//	@description	Exchange an email and password for an access token and a refresh token
+//	@tags			auth
+//	@accept			json
+//	@produce		json
+//	@param			body	body		LoginForm	true	"Credentials"
+//	@success		200		{object}	TokenDTO
+//	@failure		400		{object}	err.Error
+//	@failure		401		{object}	err.Error
+//	@failure		422		{object}	err.Errors
+//	@failure		500		{object}	err.Error
+//	@router			/auth/login [post]
+func (a *API) Login(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	form := &LoginForm{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.BadRequest(w, e.RespJSONDecodeFailure)
+		return
+	}
+
+	if err := a.validator.Struct(form); err != nil {
+		respBody, err := json.Marshal(validatorUtil.ToErrResponse(err))
+		if err != nil {
+			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+			e.ServerError(w, e.RespJSONEncodeFailure)
+			return
+		}
+
+		e.ValidationErrors(w, respBody)
+		return
+	}
+
+	user, err := a.users.ReadByEmail(r.Context(), form.Email)
+	if err != nil && err != sql.ErrNoRows {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataAccessFailure)
+		return
+	}
+	if user == nil {
+		user = &users.User{PasswordHash: dummyPasswordHash}
+	}
+
+	if !user.CheckPassword(form.Password) || !user.Active {
+		a.logger.Info().Str(l.KeyReqID, reqID).Msg("login failed")
+		e.Unauthorized(w, e.RespInvalidCredentials)
+		return
+	}
+
+	refreshToken, err := a.repository.Create(r.Context(), user.ID, time.Now().Add(a.options.RefreshTokenTTL))
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataInsertFailure)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("user_id", user.ID).Msg("user logged in")
+	a.writeTokens(w, reqID, user.ID, refreshToken)
+}
+
+// Refresh godoc
+//
+//	@summary		Refresh tokens
+//	@description	Exchange a refresh token for a new access token and a new refresh token. The refresh token is single use.
+//	@tags			auth
+//	@accept			json
+//	@produce		json
+//	@param			body	body		RefreshForm	true	"Refresh token"
+//	@success		200		{object}	TokenDTO
+//	@failure		400		{object}	err.Error
+//	@failure		401		{object}	err.Error
+//	@failure		422		{object}	err.Errors
+//	@failure		500		{object}	err.Error
+//	@router			/auth/refresh [post]
+func (a *API) Refresh(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	form, ok := a.decodeRefreshForm(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	userID, refreshToken, err := a.repository.Rotate(
+		r.Context(),
+		form.RefreshToken,
+		time.Now().Add(a.options.RefreshTokenTTL),
+	)
+	if err != nil {
+		if errors.Is(err, ErrInvalidRefreshToken) {
+			e.Unauthorized(w, e.RespInvalidRefreshToken)
+			return
+		}
+
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataUpdateFailure)
+		return
+	}
+
+	a.writeTokens(w, reqID, userID, refreshToken)
+}
+
+// Logout godoc
+//
+//	@summary		Log out
+//	@description	Revoke a refresh token. Access tokens already issued stay valid until they expire.
+//	@tags			auth
+//	@accept			json
+//	@produce		json
+//	@param			body	body	RefreshForm	true	"Refresh token"
+//	@success		204
+//	@failure		400	{object}	err.Error
+//	@failure		401	{object}	err.Error
+//	@failure		422	{object}	err.Errors
+//	@failure		500	{object}	err.Error
+//	@router			/auth/logout [post]
+func (a *API) Logout(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	form, ok := a.decodeRefreshForm(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	revoked, err := a.repository.Revoke(r.Context(), form.RefreshToken)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataUpdateFailure)
+		return
+	}
+	if !revoked {
+		e.Unauthorized(w, e.RespInvalidRefreshToken)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *API) decodeRefreshForm(w http.ResponseWriter, r *http.Request, reqID string) (*RefreshForm, bool) {
+	form := &RefreshForm{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.BadRequest(w, e.RespJSONDecodeFailure)
+		return nil, false
+	}
+
+	if err := a.validator.Struct(form); err != nil {
+		respBody, err := json.Marshal(validatorUtil.ToErrResponse(err))
+		if err != nil {
+			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+			e.ServerError(w, e.RespJSONEncodeFailure)
+			return nil, false
+		}
+
+		e.ValidationErrors(w, respBody)
+		return nil, false
+	}
+
+	return form, true
+}
+
+// writeTokens signs an access token for the user and writes it with
+// refreshToken.
+func (a *API) writeTokens(w http.ResponseWriter, reqID string, userID uint64, refreshToken string) {
+	now := time.Now()
+
+	claims := &jwt.Claims{
+		Subject:   strconv.FormatUint(userID, 10),
+		Issuer:    a.options.Issuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(a.options.AccessTokenTTL).Unix(),
+	}
+	if a.options.Audience != "" {
+		claims.Audience = jwt.Audience{a.options.Audience}
+	}
+
+	accessToken, err := a.options.Signer.Sign(claims)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespTokenIssueFailure)
+		return
+	}
+
+	dto := &TokenDTO{
+		AccessToken:  accessToken,
+		TokenType:    tokenTypeBearer,
+		ExpiresIn:    int64(a.options.AccessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+	}
+	if err := json.NewEncoder(w).Encode(dto); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespJSONEncodeFailure)
+		return
+	}
+}
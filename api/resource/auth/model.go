@@ -0,0 +1,18 @@
+package auth
+
+type LoginForm struct {
+	Email    string `json:"email"    form:"required,email"`
+	Password string `json:"password" form:"required"`
+}
+
+type RefreshForm struct {
+	RefreshToken string `json:"refresh_token" form:"required"`
+}
+
+// TokenDTO is an OAuth 2 style token response.
+type TokenDTO struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
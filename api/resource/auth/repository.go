@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"time"
+)
+
+// ErrInvalidRefreshToken is returned for an unknown, expired or revoked
+// refresh token, or one of an inactive user.
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// newRefreshToken returns a random refresh token and the hash stored for it.
+func newRefreshToken() (token string, hash []byte, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashRefreshToken(token), nil
+}
+
+func hashRefreshToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// Create stores a new refresh token of the user and returns it.
+func (r *Repository) Create(ctx context.Context, userID uint64, expiresAt time.Time) (string, error) {
+	token, hash, err := newRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = r.db.ExecContext(
+		ctx,
+		"INSERT INTO public.refresh_token (usuario_id, token_hash, expires_at) VALUES ($1, $2, $3);",
+		userID,
+		hash,
+		expiresAt,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Rotate revokes token and replaces it with a new refresh token of the same
+// user, returned with the user ID.
+//
+// A token that was already revoked may have been stolen, so presenting it
+// again revokes every token of its user, forcing a new login.
+func (r *Repository) Rotate(ctx context.Context, token string, expiresAt time.Time) (userID uint64, newToken string, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	defer tx.Rollback()
+
+	var (
+		id      uint64
+		expired bool
+		revoked bool
+		active  bool
+	)
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT rt.id, rt.usuario_id, rt.expires_at <= now(), rt.revoked_at IS NOT NULL, u.ativo
+		FROM public.refresh_token rt
+		JOIN public.usuario u ON u.id = rt.usuario_id
+		WHERE rt.token_hash = $1
+		FOR UPDATE OF rt;`,
+		hashRefreshToken(token),
+	).Scan(&id, &userID, &expired, &revoked, &active)
+	if err == sql.ErrNoRows {
+		return 0, "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return 0, "", err
+	}
+
+	if revoked {
+		if err := revokeUser(ctx, tx, userID); err != nil {
+			return 0, "", err
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, "", err
+		}
+		return 0, "", ErrInvalidRefreshToken
+	}
+	if expired || !active {
+		return 0, "", ErrInvalidRefreshToken
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE public.refresh_token SET revoked_at = now() WHERE id = $1;", id)
+	if err != nil {
+		return 0, "", err
+	}
+
+	newToken, hash, err := newRefreshToken()
+	if err != nil {
+		return 0, "", err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO public.refresh_token (usuario_id, token_hash, expires_at) VALUES ($1, $2, $3);",
+		userID,
+		hash,
+		expiresAt,
+	)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return userID, newToken, tx.Commit()
+}
+
+// Revoke revokes token, reporting whether it was valid.
+func (r *Repository) Revoke(ctx context.Context, token string) (bool, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE public.refresh_token SET revoked_at = now()
+		WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > now();`,
+		hashRefreshToken(token),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func revokeUser(ctx context.Context, tx *sql.Tx, userID uint64) error {
+	_, err := tx.ExecContext(
+		ctx,
+		"UPDATE public.refresh_token SET revoked_at = now() WHERE usuario_id = $1 AND revoked_at IS NULL;",
+		userID,
+	)
+
+	return err
+}
@@ -13,8 +13,46 @@ var (
 	RespJSONEncodeFailure = []byte(`{"error": "json encode failure"}`)
 	RespJSONDecodeFailure = []byte(`{"error": "json decode failure"}`)
 
-	RespInvalidURLParamID     = []byte(`{"error": "invalid url param-id"}`)
-	RespInvalidQueryParamPage = []byte(`{"error": "invalid query param-page"}`)
+	RespInvalidURLParamID       = []byte(`{"error": "invalid url param-id"}`)
+	RespInvalidQueryParamPage   = []byte(`{"error": "invalid query param-page"}`)
+	RespInvalidQueryParamQ      = []byte(`{"error": "invalid query param-q"}`)
+	RespInvalidQueryParamLimit  = []byte(`{"error": "invalid query param-limit"}`)
+	RespInvalidQueryParamCursor = []byte(`{"error": "invalid query param-cursor"}`)
+	RespInvalidQueryParamSort   = []byte(`{"error": "invalid query param-sort"}`)
+	RespInvalidQueryParamFields = []byte(`{"error": "invalid query param-fields"}`)
+	RespInvalidQueryParamExpand = []byte(`{"error": "invalid query param-expand"}`)
+	RespInvalidQueryParamFacets = []byte(`{"error": "invalid query param-facets"}`)
+
+	RespInvalidQueryParamLat      = []byte(`{"error": "invalid query param-lat"}`)
+	RespInvalidQueryParamLng      = []byte(`{"error": "invalid query param-lng"}`)
+	RespInvalidQueryParamRadiusKm = []byte(`{"error": "invalid query param-radius_km"}`)
+
+	RespInvalidQueryParamOpenNow = []byte(`{"error": "invalid query param-open_now"}`)
+	RespInvalidQueryParamOpenAt  = []byte(`{"error": "invalid query param-open_at"}`)
+	RespOpenNowWithOpenAt        = []byte(`{"error": "open_now and open_at can not be used together"}`)
+
+	RespMissingBearerToken = []byte(`{"error": "missing bearer token"}`)
+	RespInvalidBearerToken = []byte(`{"error": "invalid bearer token"}`)
+	RespInvalidAPIKey      = []byte(`{"error": "invalid api key"}`)
+	RespAPIKeyIssueFailure = []byte(`{"error": "api key issue failure"}`)
+	RespRateLimitExceeded  = []byte(`{"error": "rate limit exceeded"}`)
+
+	RespInvalidCredentials  = []byte(`{"error": "invalid email or password"}`)
+	RespInvalidRefreshToken = []byte(`{"error": "invalid refresh token"}`)
+	RespPasswordHashFailure = []byte(`{"error": "password hash failure"}`)
+	RespTokenIssueFailure   = []byte(`{"error": "token issue failure"}`)
+
+	RespNameTaken = []byte(`{"error": "name is already taken"}`)
+	RespInUse     = []byte(`{"error": "still referred to by places"}`)
+
+	RespChangeReviewed = []byte(`{"error": "change was already reviewed"}`)
+
+	RespEmailTaken          = []byte(`{"errors": ["email is already taken"]}`)
+	RespUnknownRoleRegional = []byte(`{"errors": ["roles must be scoped to existing regionals"]}`)
+
+	RespForbidden         = []byte(`{"error": "forbidden"}`)
+	RespForbiddenRegional = []byte(`{"error": "forbidden outside the regionals of the user"}`)
+	RespForbiddenScope    = []byte(`{"error": "forbidden by the scopes of the api key"}`)
 )
 
 type Error struct {
@@ -35,6 +73,26 @@ func BadRequest(w http.ResponseWriter, error []byte) {
 	w.Write(error)
 }
 
+func Unauthorized(w http.ResponseWriter, error []byte) {
+	w.WriteHeader(http.StatusUnauthorized)
+	w.Write(error)
+}
+
+func Forbidden(w http.ResponseWriter, error []byte) {
+	w.WriteHeader(http.StatusForbidden)
+	w.Write(error)
+}
+
+func TooManyRequests(w http.ResponseWriter, error []byte) {
+	w.WriteHeader(http.StatusTooManyRequests)
+	w.Write(error)
+}
+
+func Conflict(w http.ResponseWriter, error []byte) {
+	w.WriteHeader(http.StatusConflict)
+	w.Write(error)
+}
+
 func ValidationErrors(w http.ResponseWriter, reps []byte) {
 	w.WriteHeader(http.StatusUnprocessableEntity)
 	w.Write(reps)
@@ -1,10 +1,16 @@
 package places
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
@@ -12,7 +18,13 @@ import (
 
 	e "cuide/api/resource/common/err"
 	l "cuide/api/resource/common/log"
+	"cuide/api/resource/regionals"
+	"cuide/api/resource/synonyms"
 	ctxUtil "cuide/util/ctx"
+	"cuide/util/geo"
+	"cuide/util/geocoder"
+	"cuide/util/query"
+	"cuide/util/rbac"
 	validatorUtil "cuide/util/validator"
 )
 
@@ -20,13 +32,35 @@ type API struct {
 	logger     *zerolog.Logger
 	validator  *validator.Validate
 	repository *Repository
+	synonyms   *synonyms.Repository
+	regionals  *regionals.Repository
+	options    Options
 }
 
-func New(logger *zerolog.Logger, validator *validator.Validate, db *sql.DB) *API {
+// Options holds the settings of the places API that come from the
+// configuration.
+type Options struct {
+	// Geocoder finds the coordinates of an address when the form has none.
+	// It may be nil.
+	Geocoder geocoder.Geocoder
+	// Regionals locates the regional of a place from its coordinates.
+	Regionals *regionals.Repository
+	// Hotlines are listed by Urgent when no place is open.
+	Hotlines []*Hotline
+	// DefaultLimit is the page size when the limit param is omitted, and
+	// MaxLimit the largest one accepted.
+	DefaultLimit uint64
+	MaxLimit     uint64
+}
+
+func New(logger *zerolog.Logger, validator *validator.Validate, db *sql.DB, options Options) *API {
 	return &API{
 		logger:     logger,
 		validator:  validator,
 		repository: NewRepository(db),
+		synonyms:   synonyms.NewRepository(db),
+		regionals:  options.Regionals,
+		options:    options,
 	}
 }
 
@@ -37,27 +71,57 @@ func New(logger *zerolog.Logger, validator *validator.Validate, db *sql.DB) *API
 //	@tags			place
 //	@accept			json
 //	@produce		json
-//	@success		200	{array}		DTO
-//	@failure		500	{object}	err.Error
+//	@param			cursor	query		string	false	"Cursor of the page, from next_cursor or prev_cursor"
+//	@param			limit	query		int		false	"Page size"
+//	@param			page	query		int		false	"Page, ignored when cursor is given"
+//	@param			sort	query		string	false	"Comma separated sort keys, reversed by a - prefix: name, updated_at"
+//	@param			fields	query		string	false	"Comma separated fields to return, id is always returned"
+//	@param			expand	query		string	false	"Comma separated relations to embed, the others come as IDs: service_type, segment, regionals"
+//	@param			facets	query		bool	false	"Count the places of each service type, segment and regional in facets"
+//	@success		200		{object}	PaginationMetadata
+//	@failure		400		{object}	err.Error
+//	@failure		500		{object}	err.Error
 //	@router			/places [get]
 func (a *API) List(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 
-	page, err := strconv.ParseUint(r.URL.Query().Get("page"), 10, 8)
-	if err != nil {
-		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
-		e.ServerError(w, e.RespInvalidQueryParamPage)
+	page, errResp := a.parsePage(r)
+	if errResp != nil {
+		e.BadRequest(w, errResp)
+		return
+	}
+
+	sort, errResp := parseSort(r, Filters{})
+	if errResp != nil {
+		e.BadRequest(w, errResp)
 		return
 	}
 
-	places, err := a.repository.List(uint8(page))
+	projection, errResp := parseProjection(r)
+	if errResp != nil {
+		e.BadRequest(w, errResp)
+		return
+	}
+
+	places, cursors, err := a.repository.List(sort, page)
 	if err != nil {
+		if errors.Is(err, query.ErrInvalidCursor) {
+			e.BadRequest(w, e.RespInvalidQueryParamCursor)
+			return
+		}
+
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
 		e.ServerError(w, e.RespDBDataAccessFailure)
 		return
 	}
 
-	paginationMetadata, err := a.repository.PaginationMetadata()
+	facets, errResp := parseFacets(r)
+	if errResp != nil {
+		e.BadRequest(w, errResp)
+		return
+	}
+
+	paginationMetadata, err := a.repository.FilterMetadata(Filters{Facets: facets}, page.Limit)
 	if err != nil {
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
 		e.ServerError(w, e.RespDBDataAccessFailure)
@@ -65,6 +129,8 @@ func (a *API) List(w http.ResponseWriter, r *http.Request) {
 	}
 
 	paginationMetadata.Places = places.ToDto()
+	projection.Apply(paginationMetadata.Places...)
+	paginationMetadata.setPage(page, cursors)
 
 	if err := json.NewEncoder(w).Encode(paginationMetadata); err != nil {
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
@@ -76,13 +142,16 @@ func (a *API) List(w http.ResponseWriter, r *http.Request) {
 // Create godoc
 //
 //	@summary		Create places
-//	@description	Create places
+//	@description	Create places. Principals that may not review the place submit a change, for a reviewer to approve.
 //	@tags			place
 //	@accept			json
 //	@produce		json
 //	@param			body	body	Form	true	"Place form"
 //	@success		201
+//	@success		202	{object}	ChangeDTO
 //	@failure		400	{object}	err.Error
+//	@failure		401	{object}	err.Error
+//	@failure		403	{object}	err.Error
 //	@failure		422	{object}	err.Errors
 //	@failure		500	{object}	err.Error
 //	@router			/places [post]
@@ -109,6 +178,28 @@ func (a *API) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	newPlace := form.ToModel()
+	a.geocode(r.Context(), reqID, &newPlace)
+	newPlace.deriveEmbedLink()
+
+	if !a.locateRegional(w, reqID, &newPlace) {
+		return
+	}
+
+	principal := ctxUtil.Principal(r.Context())
+	if !canAssignRegionals(principal, nil, newPlace.Regionals.IDs()) {
+		e.Forbidden(w, e.RespForbiddenRegional)
+		return
+	}
+
+	if !canReviewPlace(principal, newPlace.Regionals.IDs()) {
+		form.RegionalIDs = newPlace.Regionals.IDs()
+		a.submitChange(w, r, &Change{
+			Action:      ChangeCreate,
+			Form:        form,
+			RegionalIDs: form.RegionalIDs,
+		})
+		return
+	}
 
 	referenceWay, err := a.repository.Create(r.Context(), &newPlace)
 	if err != nil {
@@ -117,7 +208,7 @@ func (a *API) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	a.logger.Info().Str(l.KeyReqID, reqID).Uint8("id", referenceWay.ID).Msg("new place created")
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", referenceWay.ID).Msg("new place created")
 	w.WriteHeader(http.StatusCreated)
 }
 
@@ -128,22 +219,30 @@ func (a *API) Create(w http.ResponseWriter, r *http.Request) {
 //	@tags			place
 //	@accept			json
 //	@produce		json
-//	@param			id	path		string	true	"Reference Way ID"
-//	@success		200	{object}	DTO
-//	@failure		400	{object}	err.Error
+//	@param			id		path		string	true	"Reference Way ID"
+//	@param			fields	query		string	false	"Comma separated fields to return, id is always returned"
+//	@param			expand	query		string	false	"Comma separated relations to embed, the others come as IDs: service_type, segment, regionals"
+//	@success		200		{object}	DTO
+//	@failure		400		{object}	err.Error
 //	@failure		404
-//	@failure		500	{object}	err.Error
+//	@failure		500		{object}	err.Error
 //	@router			/places/{id} [get]
 func (a *API) Read(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 
-	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 8)
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
 		e.BadRequest(w, e.RespInvalidURLParamID)
 		return
 	}
 
-	place, err := a.repository.Read(uint8(id))
+	projection, errResp := parseProjection(r)
+	if errResp != nil {
+		e.BadRequest(w, errResp)
+		return
+	}
+
+	place, err := a.repository.Read(id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			w.WriteHeader(http.StatusNotFound)
@@ -156,6 +255,7 @@ func (a *API) Read(w http.ResponseWriter, r *http.Request) {
 	}
 
 	dto := place.ToDto()
+	projection.Apply(dto)
 	if err := json.NewEncoder(w).Encode(dto); err != nil {
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
 		e.ServerError(w, e.RespJSONEncodeFailure)
@@ -163,49 +263,217 @@ func (a *API) Read(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// Read godoc
+// Filter godoc
 //
-//	@summary		Read place
-//	@description	Read place
+//	@summary		Filter places
+//	@description	Filter places. With facets=true, the facets count the places each service type, segment or regional would return with the other filters kept.
 //	@tags			place
 //	@accept			json
 //	@produce		json
-//	@param			id	path		string	true	"Place ID"
-//	@success		200	{object}	DTO
-//	@failure		400	{object}	err.Error
+//	@param			cursor				query		string	false	"Cursor of the page, from next_cursor or prev_cursor"
+//	@param			limit				query		int		false	"Page size"
+//	@param			page				query		int		false	"Page, ignored when cursor is given"
+//	@param			sort				query		string	false	"Comma separated sort keys, reversed by a - prefix: name, distance, updated_at, relevance"
+//	@param			fields				query		string	false	"Comma separated fields to return, id is always returned"
+//	@param			expand				query		string	false	"Comma separated relations to embed, the others come as IDs: service_type, segment, regionals"
+//	@param			facets				query		bool	false	"Count the places of each service type, segment and regional in facets"
+//	@param			service-type		query		[]int	false	"Service type IDs"
+//	@param			segment				query		[]int	false	"Segment IDs"
+//	@param			regional			query		[]int	false	"Regional IDs"
+//	@param			admission-criteria	query		[]int	false	"Admission criteria IDs"
+//	@param			referral-way		query		[]int	false	"Referral way IDs"
+//	@param			attendance-type		query		[]int	false	"Attendance type IDs"
+//	@param			name				query		string	false	"Name"
+//	@param			q					query		string	false	"Full-text search expanded with synonyms, results ranked by relevance"
+//	@param			open_now			query		bool	false	"Only places open now"
+//	@param			open_at				query		string	false	"Only places open at this RFC3339 time, not with open_now"
+//	@success		200					{object}	PaginationMetadata
+//	@failure		400					{object}	err.Error
 //	@failure		404
-//	@failure		500	{object}	err.Error
-//	@router			/places/{id} [get]
+//	@failure		500					{object}	err.Error
+//	@router			/places/filter [get]
 func (a *API) Filter(w http.ResponseWriter, r *http.Request) {
+	filters, errResp := parseFilters(r)
+	if errResp != nil {
+		e.BadRequest(w, errResp)
+		return
+	}
+
+	a.filter(w, r, filters)
+}
+
+const (
+	nearbyDefaultRadiusKm = 10
+	nearbyMaxRadiusKm     = 100
+)
+
+// Nearby godoc
+//
+//	@summary		List nearby places
+//	@description	List places within a radius of a point, closest first. Accepts the same filters as /places/filter.
+//	@tags			place
+//	@accept			json
+//	@produce		json
+//	@param			lat					query		number	true	"Latitude"
+//	@param			lng					query		number	true	"Longitude"
+//	@param			radius_km			query		number	false	"Radius in kilometres (default 10, max 100)"
+//	@param			cursor				query		string	false	"Cursor of the page, from next_cursor or prev_cursor"
+//	@param			limit				query		int		false	"Page size"
+//	@param			page				query		int		false	"Page, ignored when cursor is given"
+//	@param			sort				query		string	false	"Comma separated sort keys, reversed by a - prefix: name, distance, updated_at, relevance"
+//	@param			fields				query		string	false	"Comma separated fields to return, id is always returned"
+//	@param			expand				query		string	false	"Comma separated relations to embed, the others come as IDs: service_type, segment, regionals"
+//	@param			facets				query		bool	false	"Count the places of each service type, segment and regional in facets"
+//	@param			service-type		query		[]int	false	"Service type IDs"
+//	@param			segment				query		[]int	false	"Segment IDs"
+//	@param			regional			query		[]int	false	"Regional IDs"
+//	@param			admission-criteria	query		[]int	false	"Admission criteria IDs"
+//	@param			referral-way		query		[]int	false	"Referral way IDs"
+//	@param			attendance-type		query		[]int	false	"Attendance type IDs"
+//	@param			name				query		string	false	"Name"
+//	@param			q					query		string	false	"Full-text search expanded with synonyms"
+//	@param			open_now			query		bool	false	"Only places open now"
+//	@param			open_at				query		string	false	"Only places open at this RFC3339 time, not with open_now"
+//	@success		200					{object}	PaginationMetadata
+//	@failure		400					{object}	err.Error
+//	@failure		500					{object}	err.Error
+//	@router			/places/nearby [get]
+func (a *API) Nearby(w http.ResponseWriter, r *http.Request) {
+	lat, lng, errResp := parseCoordinates(r)
+	if errResp != nil {
+		e.BadRequest(w, errResp)
+		return
+	}
+
+	radiusKm := float64(nearbyDefaultRadiusKm)
+	if v := r.URL.Query().Get("radius_km"); v != "" {
+		var err error
+		radiusKm, err = strconv.ParseFloat(v, 64)
+		if err != nil || radiusKm <= 0 || radiusKm > nearbyMaxRadiusKm {
+			e.BadRequest(w, e.RespInvalidQueryParamRadiusKm)
+			return
+		}
+	}
+
+	filters, errResp := parseFilters(r)
+	if errResp != nil {
+		e.BadRequest(w, errResp)
+		return
+	}
+
+	filters.Near = &Near{
+		Latitude:  lat,
+		Longitude: lng,
+		RadiusKm:  radiusKm,
+	}
+
+	a.filter(w, r, filters)
+}
+
+const urgentLimit = 20
+
+// Urgent godoc
+//
+//	@summary		List urgent places
+//	@description	List places open now that take walk-ins, closest first when lat and lng are given. When none is open, the configured hotlines are returned instead.
+//	@tags			place
+//	@accept			json
+//	@produce		json
+//	@param			lat	query		number	false	"Latitude"
+//	@param			lng	query		number	false	"Longitude"
+//	@success		200	{object}	UrgentResponse
+//	@failure		400	{object}	err.Error
+//	@failure		500	{object}	err.Error
+//	@router			/places/urgent [get]
+func (a *API) Urgent(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 
-	page, err := strconv.ParseUint(r.URL.Query().Get("page"), 10, 8)
+	now := time.Now()
+	filters := Filters{
+		OpenAt:            &now,
+		SpontaneousDemand: true,
+	}
+
+	if r.URL.Query().Has("lat") || r.URL.Query().Has("lng") {
+		lat, lng, errResp := parseCoordinates(r)
+		if errResp != nil {
+			e.BadRequest(w, errResp)
+			return
+		}
+
+		filters.Near = &Near{
+			Latitude:  lat,
+			Longitude: lng,
+		}
+	}
+
+	places, _, err := a.repository.Filter(filters, Page{Limit: urgentLimit})
 	if err != nil {
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
-		e.ServerError(w, e.RespInvalidQueryParamPage)
+		e.ServerError(w, e.RespDBDataAccessFailure)
 		return
 	}
 
-	filters := Filters{
-		ServiceTypes: parseUint8SliceQuery(r, "service-type"),
-		Segments:     parseUint8SliceQuery(r, "segment"),
-		Regionals:    parseUint8SliceQuery(r, "regional"),
-		Name:         parseStringSliceQuery(r, "name"),
+	resp := UrgentResponse{
+		Places: places.ToDto(),
+	}
+	if len(places) == 0 {
+		resp.Hotlines = a.options.Hotlines
+	}
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespJSONEncodeFailure)
+		return
+	}
+}
+
+func (a *API) filter(w http.ResponseWriter, r *http.Request, filters Filters) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	page, errResp := a.parsePage(r)
+	if errResp != nil {
+		e.BadRequest(w, errResp)
+		return
 	}
 
-	places, conditionals, err := a.repository.Filter(filters, uint8(page))
+	filters.Sort, errResp = parseSort(r, filters)
+	if errResp != nil {
+		e.BadRequest(w, errResp)
+		return
+	}
+
+	projection, errResp := parseProjection(r)
+	if errResp != nil {
+		e.BadRequest(w, errResp)
+		return
+	}
+
+	var err error
+	filters.QueryTerms, err = a.synonyms.Expand(r.Context(), filters.Query)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataAccessFailure)
+		return
+	}
+
+	places, cursors, err := a.repository.Filter(filters, page)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
+		if errors.Is(err, query.ErrInvalidCursor) {
+			e.BadRequest(w, e.RespInvalidQueryParamCursor)
+			return
+		}
 
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
 		e.ServerError(w, e.RespDBDataAccessFailure)
 		return
 	}
 
-	paginationMetadata, err := a.repository.FilterPaginationMetadata(conditionals)
+	paginationMetadata, err := a.repository.FilterMetadata(filters, page.Limit)
 	if err != nil {
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
 		e.ServerError(w, e.RespDBDataAccessFailure)
@@ -213,6 +481,8 @@ func (a *API) Filter(w http.ResponseWriter, r *http.Request) {
 	}
 
 	paginationMetadata.Places = places.ToDto()
+	projection.Apply(paginationMetadata.Places...)
+	paginationMetadata.setPage(page, cursors)
 
 	if err := json.NewEncoder(w).Encode(paginationMetadata); err != nil {
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
@@ -221,13 +491,256 @@ func (a *API) Filter(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func parseUint8SliceQuery(r *http.Request, param string) []uint8 {
+// parsePage reads the cursor, limit and legacy page query params. On an
+// invalid one it returns the error response to send with a 400.
+func (a *API) parsePage(r *http.Request) (Page, []byte) {
+	page := Page{
+		Limit:  a.options.DefaultLimit,
+		Cursor: r.URL.Query().Get("cursor"),
+	}
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || limit == 0 || limit > a.options.MaxLimit {
+			return page, e.RespInvalidQueryParamLimit
+		}
+		page.Limit = limit
+	}
+
+	if v := r.URL.Query().Get("page"); v != "" && page.Cursor == "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || n == 0 {
+			return page, e.RespInvalidQueryParamPage
+		}
+		page.Offset = (n - 1) * page.Limit
+	}
+
+	return page, nil
+}
+
+// parseSort reads the sort query param: a comma separated list of keys,
+// each reversed by a "-" prefix. distance needs a point and relevance a
+// search, so they are rejected without them.
+func parseSort(r *http.Request, filters Filters) ([]Sort, []byte) {
+	var sorts []Sort
+	seen := make(map[string]bool)
+
+	for _, param := range r.URL.Query()["sort"] {
+		for _, key := range strings.Split(param, ",") {
+			key = strings.TrimSpace(key)
+			if key == "" {
+				continue
+			}
+
+			sort := Sort{Key: strings.TrimPrefix(key, "-")}
+			sort.Desc = sort.Key != key
+
+			switch sort.Key {
+			case SortName, SortUpdatedAt:
+			case SortDistance:
+				if filters.Near == nil {
+					return nil, e.RespInvalidQueryParamSort
+				}
+			case SortRelevance:
+				if len(strings.Fields(filters.Query)) == 0 {
+					return nil, e.RespInvalidQueryParamSort
+				}
+			default:
+				return nil, e.RespInvalidQueryParamSort
+			}
+
+			if seen[sort.Key] {
+				return nil, e.RespInvalidQueryParamSort
+			}
+			seen[sort.Key] = true
+
+			sorts = append(sorts, sort)
+		}
+	}
+
+	return sorts, nil
+}
+
+// parseProjection reads the fields and expand query params, each a comma
+// separated list of names. Without them every field comes back, with the
+// relations embedded. On an unknown name it returns the error response to
+// send with a 400.
+func parseProjection(r *http.Request) (*Projection, []byte) {
+	p := &Projection{}
+	q := r.URL.Query()
+
+	if q.Has("fields") {
+		p.Fields = make(map[string]bool)
+		for _, name := range splitQueryList(q["fields"]) {
+			if !isField(name) {
+				return nil, e.RespInvalidQueryParamFields
+			}
+			p.Fields[name] = true
+		}
+	}
+
+	if q.Has("expand") {
+		p.Expand = make(map[string]bool)
+		for _, name := range splitQueryList(q["expand"]) {
+			if !isRelation(name) {
+				return nil, e.RespInvalidQueryParamExpand
+			}
+			p.Expand[name] = true
+		}
+	}
+
+	return p, nil
+}
+
+// parseFacets reads the facets query param, false when it is omitted. On an
+// invalid one it returns the error response to send with a 400.
+func parseFacets(r *http.Request) (bool, []byte) {
+	v := r.URL.Query().Get("facets")
+	if v == "" {
+		return false, nil
+	}
+
+	facets, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, e.RespInvalidQueryParamFacets
+	}
+
+	return facets, nil
+}
+
+// splitQueryList splits comma separated query param values, skipping empty
+// names.
+func splitQueryList(values []string) []string {
+	var names []string
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
+}
+
+// parseCoordinates reads the lat and lng query params. On an invalid one it
+// returns the error response to send with a 400.
+func parseCoordinates(r *http.Request) (lat, lng float64, errResp []byte) {
+	lat, lng, err := geo.ParseCoordinates(r.URL.Query().Get("lat"), r.URL.Query().Get("lng"))
+	if err == geo.ErrInvalidLatitude {
+		return 0, 0, e.RespInvalidQueryParamLat
+	}
+	if err != nil {
+		return 0, 0, e.RespInvalidQueryParamLng
+	}
+
+	return lat, lng, nil
+}
+
+// parseFilters reads the filters shared by Filter and Nearby. On an invalid
+// parameter it returns the error response to send with a 400.
+func parseFilters(r *http.Request) (Filters, []byte) {
+	filters := Filters{
+		ServiceTypes:      parseUint64SliceQuery(r, "service-type"),
+		Segments:          parseUint64SliceQuery(r, "segment"),
+		Regionals:         parseUint64SliceQuery(r, "regional"),
+		AdmissionCriteria: parseUint64SliceQuery(r, "admission-criteria"),
+		ReferralWays:      parseUint64SliceQuery(r, "referral-way"),
+		AttendanceTypes:   parseUint64SliceQuery(r, "attendance-type"),
+		Name:              parseStringSliceQuery(r, "name"),
+		Query:             parseStringSliceQuery(r, "q"),
+	}
+
+	var errResp []byte
+	filters.Facets, errResp = parseFacets(r)
+	if errResp != nil {
+		return filters, errResp
+	}
+
+	if r.URL.Query().Get("open_now") != "" && r.URL.Query().Get("open_at") != "" {
+		return filters, e.RespOpenNowWithOpenAt
+	}
+
+	if v := r.URL.Query().Get("open_now"); v != "" {
+		openNow, err := strconv.ParseBool(v)
+		if err != nil {
+			return filters, e.RespInvalidQueryParamOpenNow
+		}
+		if openNow {
+			now := time.Now()
+			filters.OpenAt = &now
+		}
+	}
+
+	if v := r.URL.Query().Get("open_at"); v != "" {
+		openAt, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filters, e.RespInvalidQueryParamOpenAt
+		}
+		filters.OpenAt = &openAt
+	}
+
+	return filters, nil
+}
+
+const (
+	suggestDefaultLimit = 10
+	suggestMaxLimit     = 25
+)
+
+// Suggest godoc
+//
+//	@summary		Suggest place names
+//	@description	Suggest place, segment, service type and regional names by trigram similarity
+//	@tags			place
+//	@accept			json
+//	@produce		json
+//	@param			q		query		string	true	"Partial or mistyped name"
+//	@param			limit	query		int		false	"Maximum number of suggestions"
+//	@success		200		{array}		Suggestion
+//	@failure		400		{object}	err.Error
+//	@failure		500		{object}	err.Error
+//	@router			/places/suggest [get]
+func (a *API) Suggest(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		e.BadRequest(w, e.RespInvalidQueryParamQ)
+		return
+	}
+
+	limit := suggestDefaultLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > suggestMaxLimit {
+			e.BadRequest(w, e.RespInvalidQueryParamLimit)
+			return
+		}
+		limit = n
+	}
+
+	suggestions, err := a.repository.Suggest(r.Context(), q, limit)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataAccessFailure)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(suggestions); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespJSONEncodeFailure)
+		return
+	}
+}
+
+func parseUint64SliceQuery(r *http.Request, param string) []uint64 {
 	values := r.URL.Query()[param]
-	var result []uint8
+	var result []uint64
 	for _, val := range values {
-		parsedVal, err := strconv.ParseUint(val, 10, 8)
+		parsedVal, err := strconv.ParseUint(val, 10, 64)
 		if err == nil {
-			result = append(result, uint8(parsedVal))
+			result = append(result, parsedVal)
 		}
 	}
 	return result
@@ -246,14 +759,17 @@ func parseStringSliceQuery(r *http.Request, param string) string {
 // Update godoc
 //
 //	@summary		Update place
-//	@description	Update place
+//	@description	Update place. Principals that may not review the place submit a change, for a reviewer to approve.
 //	@tags			places
 //	@accept			json
 //	@produce		json
 //	@param			id		path	string	true	"Place ID"
 //	@param			body	body	Form	true	"Place form"
 //	@success		200
+//	@success		202	{object}	ChangeDTO
 //	@failure		400	{object}	err.Error
+//	@failure		401	{object}	err.Error
+//	@failure		403	{object}	err.Error
 //	@failure		404
 //	@failure		422	{object}	err.Errors
 //	@failure		500	{object}	err.Error
@@ -261,7 +777,7 @@ func parseStringSliceQuery(r *http.Request, param string) string {
 func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 
-	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 8)
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
 		e.BadRequest(w, e.RespInvalidURLParamID)
 		return
@@ -286,8 +802,48 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	current, err := a.repository.RegionalIDs(r.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataAccessFailure)
+		return
+	}
+
+	principal := ctxUtil.Principal(r.Context())
+	if !canEditPlace(principal, current) {
+		e.Forbidden(w, e.RespForbiddenRegional)
+		return
+	}
+
 	place := form.ToModel()
-	place.ID = uint8(id)
+	place.ID = id
+	a.geocode(r.Context(), reqID, &place)
+	place.deriveEmbedLink()
+
+	if !a.locateRegional(w, reqID, &place) {
+		return
+	}
+
+	if !canAssignRegionals(principal, current, place.Regionals.IDs()) {
+		e.Forbidden(w, e.RespForbiddenRegional)
+		return
+	}
+
+	if affected := unionIDs(current, place.Regionals.IDs()); !canReviewPlace(principal, affected) {
+		form.RegionalIDs = place.Regionals.IDs()
+		a.submitChange(w, r, &Change{
+			PlaceID:     &id,
+			Action:      ChangeUpdate,
+			Form:        form,
+			RegionalIDs: affected,
+		})
+		return
+	}
 
 	rows, err := a.repository.Update(r.Context(), &place)
 	if err != nil {
@@ -300,32 +856,62 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	a.logger.Info().Str(l.KeyReqID, reqID).Uint8("id", place.ID).Msg("place updated")
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", place.ID).Msg("place updated")
 }
 
 // Delete godoc
 //
 //	@summary		Delete places
-//	@description	Delete places
+//	@description	Delete places. Principals that may not review the place submit a change, for a reviewer to approve.
 //	@tags			places
 //	@accept			json
 //	@produce		json
 //	@param			id	path	string	true	"Place ID"
 //	@success		200
+//	@success		202	{object}	ChangeDTO
 //	@failure		400	{object}	err.Error
+//	@failure		401	{object}	err.Error
+//	@failure		403	{object}	err.Error
 //	@failure		404
 //	@failure		500	{object}	err.Error
 //	@router			/places/{id} [delete]
 func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 
-	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 8)
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
 		e.BadRequest(w, e.RespInvalidURLParamID)
 		return
 	}
 
-	rows, err := a.repository.Delete(uint8(id))
+	current, err := a.repository.RegionalIDs(r.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataAccessFailure)
+		return
+	}
+
+	principal := ctxUtil.Principal(r.Context())
+	if !canDeletePlace(principal, current) {
+		e.Forbidden(w, e.RespForbiddenRegional)
+		return
+	}
+
+	if !canReviewPlace(principal, current) {
+		a.submitChange(w, r, &Change{
+			PlaceID:     &id,
+			Action:      ChangeDelete,
+			RegionalIDs: current,
+		})
+		return
+	}
+
+	rows, err := a.repository.Delete(id)
 	if err != nil {
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
 		e.ServerError(w, e.RespDBDataRemoveFailure)
@@ -336,5 +922,465 @@ func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	a.logger.Info().Str(l.KeyReqID, reqID).Uint8("id", uint8(id)).Msg("place deleted")
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", id).Msg("place deleted")
+}
+
+// ListChanges godoc
+//
+//	@summary		List place changes
+//	@description	List the pending place changes the principal may review
+//	@tags			places
+//	@accept			json
+//	@produce		json
+//	@success		200	{array}		ChangeDTO
+//	@failure		401	{object}	err.Error
+//	@failure		403	{object}	err.Error
+//	@failure		500	{object}	err.Error
+//	@router			/places/changes [get]
+func (a *API) ListChanges(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	changes, err := a.repository.ListChanges(r.Context(), ChangePending)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataAccessFailure)
+		return
+	}
+
+	principal := ctxUtil.Principal(r.Context())
+	changes = slices.DeleteFunc(changes, func(c *Change) bool {
+		return !canReviewPlace(principal, c.RegionalIDs)
+	})
+
+	if err := json.NewEncoder(w).Encode(changes.ToDto()); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespJSONEncodeFailure)
+		return
+	}
+}
+
+// ReadChange godoc
+//
+//	@summary		Read place change
+//	@description	Read place change
+//	@tags			places
+//	@accept			json
+//	@produce		json
+//	@param			id	path		string	true	"Change ID"
+//	@success		200	{object}	ChangeDTO
+//	@failure		400	{object}	err.Error
+//	@failure		401	{object}	err.Error
+//	@failure		403	{object}	err.Error
+//	@failure		404
+//	@failure		500	{object}	err.Error
+//	@router			/places/changes/{id} [get]
+func (a *API) ReadChange(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	change, ok := a.readChange(w, r)
+	if !ok {
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(change.ToDto()); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespJSONEncodeFailure)
+		return
+	}
+}
+
+// ApproveChange godoc
+//
+//	@summary		Approve place change
+//	@description	Approve a pending place change, which is then applied
+//	@tags			places
+//	@accept			json
+//	@produce		json
+//	@param			id		path		string		true	"Change ID"
+//	@param			body	body		ReviewForm	false	"Review form"
+//	@success		200		{object}	ChangeDTO
+//	@failure		400		{object}	err.Error
+//	@failure		401		{object}	err.Error
+//	@failure		403		{object}	err.Error
+//	@failure		404
+//	@failure		409		{object}	err.Error
+//	@failure		422		{object}	err.Errors
+//	@failure		500		{object}	err.Error
+//	@router			/places/changes/{id}/approve [post]
+func (a *API) ApproveChange(w http.ResponseWriter, r *http.Request) {
+	a.review(w, r, ChangeApproved)
+}
+
+// RejectChange godoc
+//
+//	@summary		Reject place change
+//	@description	Reject a pending place change
+//	@tags			places
+//	@accept			json
+//	@produce		json
+//	@param			id		path		string		true	"Change ID"
+//	@param			body	body		ReviewForm	false	"Review form"
+//	@success		200		{object}	ChangeDTO
+//	@failure		400		{object}	err.Error
+//	@failure		401		{object}	err.Error
+//	@failure		403		{object}	err.Error
+//	@failure		404
+//	@failure		409		{object}	err.Error
+//	@failure		422		{object}	err.Errors
+//	@failure		500		{object}	err.Error
+//	@router			/places/changes/{id}/reject [post]
+func (a *API) RejectChange(w http.ResponseWriter, r *http.Request) {
+	a.review(w, r, ChangeRejected)
+}
+
+// review moves a pending change to status, applying it first when it is
+// approved. The change is claimed before it is applied, so that two
+// reviewers can not apply it twice, and reopened when applying fails.
+func (a *API) review(w http.ResponseWriter, r *http.Request, status string) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	form := &ReviewForm{}
+	if err := json.NewDecoder(r.Body).Decode(form); err != nil && err != io.EOF {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.BadRequest(w, e.RespJSONDecodeFailure)
+		return
+	}
+
+	if err := a.validator.Struct(form); err != nil {
+		respBody, err := json.Marshal(validatorUtil.ToErrResponse(err))
+		if err != nil {
+			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+			e.ServerError(w, e.RespJSONEncodeFailure)
+			return
+		}
+
+		e.ValidationErrors(w, respBody)
+		return
+	}
+
+	change, ok := a.readChange(w, r)
+	if !ok {
+		return
+	}
+	if change.Status != ChangePending {
+		e.Conflict(w, e.RespChangeReviewed)
+		return
+	}
+
+	principal := ctxUtil.Principal(r.Context())
+	rows, err := a.repository.ReviewChange(
+		r.Context(),
+		change.ID,
+		status,
+		principal.Subject,
+		form.Reason,
+	)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataUpdateFailure)
+		return
+	}
+	if rows == 0 {
+		e.Conflict(w, e.RespChangeReviewed)
+		return
+	}
+
+	if status == ChangeApproved && !a.apply(w, r, change) {
+		if err := a.repository.ReopenChange(r.Context(), change.ID); err != nil {
+			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		}
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", change.ID).Str("status", status).Msg("place change reviewed")
+
+	change, err = a.repository.ReadChange(r.Context(), change.ID)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataAccessFailure)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(change.ToDto()); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespJSONEncodeFailure)
+		return
+	}
+}
+
+// readChange reads the change in the id URL param, which the principal must
+// be able to review. Otherwise it writes the error response and returns
+// false.
+func (a *API) readChange(w http.ResponseWriter, r *http.Request) (*Change, bool) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		e.BadRequest(w, e.RespInvalidURLParamID)
+		return nil, false
+	}
+
+	change, err := a.repository.ReadChange(r.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return nil, false
+		}
+
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataAccessFailure)
+		return nil, false
+	}
+
+	if !canReviewPlace(ctxUtil.Principal(r.Context()), change.RegionalIDs) {
+		e.Forbidden(w, e.RespForbiddenRegional)
+		return nil, false
+	}
+
+	return change, true
+}
+
+// apply makes the place change approved by the principal of the request.
+// The principal must also be able to review the regionals the place is in
+// now, which may have changed since the change was submitted. On failure it
+// writes the error response and returns false.
+func (a *API) apply(w http.ResponseWriter, r *http.Request, change *Change) bool {
+	reqID := ctxUtil.RequestID(r.Context())
+	principal := ctxUtil.Principal(r.Context())
+
+	if change.PlaceID != nil {
+		current, err := a.repository.RegionalIDs(r.Context(), *change.PlaceID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				w.WriteHeader(http.StatusNotFound)
+				return false
+			}
+
+			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+			e.ServerError(w, e.RespDBDataAccessFailure)
+			return false
+		}
+
+		if !canReviewPlace(principal, current) {
+			e.Forbidden(w, e.RespForbiddenRegional)
+			return false
+		}
+	}
+
+	if change.Action == ChangeDelete {
+		rows, err := a.repository.Delete(*change.PlaceID)
+		if err != nil {
+			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+			e.ServerError(w, e.RespDBDataRemoveFailure)
+			return false
+		}
+		if rows == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return false
+		}
+
+		a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", *change.PlaceID).Msg("place deleted")
+		return true
+	}
+
+	place := change.Form.ToModel()
+	a.geocode(r.Context(), reqID, &place)
+	place.deriveEmbedLink()
+
+	if change.Action == ChangeUpdate {
+		place.ID = *change.PlaceID
+		rows, err := a.repository.Update(r.Context(), &place)
+		if err != nil {
+			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+			e.ServerError(w, e.RespDBDataUpdateFailure)
+			return false
+		}
+		if rows == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return false
+		}
+
+		a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", place.ID).Msg("place updated")
+		return true
+	}
+
+	if _, err := a.repository.Create(r.Context(), &place); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataInsertFailure)
+		return false
+	}
+	if err := a.repository.SetChangePlace(r.Context(), change.ID, place.ID); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", place.ID).Msg("new place created")
+	return true
+}
+
+// submitChange stores a change the principal of the request may not make
+// directly, for a reviewer to approve, and answers 202 with it.
+func (a *API) submitChange(w http.ResponseWriter, r *http.Request, change *Change) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	change.Author = ctxUtil.Principal(r.Context()).Subject
+	change, err := a.repository.CreateChange(r.Context(), change)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataInsertFailure)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", change.ID).Str("action", change.Action).Msg("place change submitted")
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(change.ToDto()); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+	}
+}
+
+// geocode fills the coordinates of place from its address when neither the
+// form nor the Maps link had them. A geocoding failure is logged but does not
+// fail the request, the place is saved without coordinates.
+func (a *API) geocode(ctx context.Context, reqID string, place *Place) {
+	if a.options.Geocoder == nil || place.Latitude != nil {
+		return
+	}
+
+	res, err := a.options.Geocoder.Geocode(ctx, place.Address)
+	if err != nil {
+		if !errors.Is(err, geocoder.ErrNotFound) {
+			a.logger.Warn().Str(l.KeyReqID, reqID).Err(err).Msg("geocoding failure")
+		}
+		return
+	}
+
+	place.Latitude, place.Longitude = &res.Latitude, &res.Longitude
+	place.Geocode = &Geocode{
+		Source:     res.Source,
+		Confidence: res.Confidence,
+	}
+}
+
+// locateRegional suggests the regional containing the coordinates of place
+// when the form has no regional_ids. When there is none to suggest, it
+// writes a validation error and returns false.
+func (a *API) locateRegional(w http.ResponseWriter, reqID string, place *Place) bool {
+	if len(place.Regionals) > 0 {
+		return true
+	}
+
+	if place.Latitude != nil && place.Longitude != nil {
+		regional, err := a.regionals.Locate(*place.Latitude, *place.Longitude)
+		switch {
+		case err == nil:
+			a.logger.Info().Str(l.KeyReqID, reqID).Uint64("regional_id", regional.ID).Msg("regional located")
+			place.Regionals = regionals.Regionals{regional}
+			return true
+		case err != sql.ErrNoRows:
+			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+			e.ServerError(w, e.RespDBDataAccessFailure)
+			return false
+		}
+	}
+
+	respBody, err := json.Marshal(validatorUtil.ErrResponse{
+		Errors: []string{"regional_ids is a required field when the place is not inside any regional boundary"},
+	})
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespJSONEncodeFailure)
+		return false
+	}
+
+	e.ValidationErrors(w, respBody)
+	return false
+}
+
+// canEditPlace reports whether the principal may edit a place in the
+// regionals current: editors scoped to regionals only edit the places in one
+// of theirs, and places in none are left to the unscoped ones.
+func canEditPlace(principal *rbac.Principal, current []uint64) bool {
+	if principal == nil {
+		return false
+	}
+	if principal.HasGlobal(rbac.PermPlacesWrite) {
+		return true
+	}
+
+	for _, id := range current {
+		if principal.HasIn(rbac.PermPlacesWrite, id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// canAssignRegionals reports whether the principal may put a place in the
+// regionals next. Besides the regionals of the principal, the place may
+// keep the ones it already has.
+func canAssignRegionals(principal *rbac.Principal, current, next []uint64) bool {
+	if principal == nil {
+		return false
+	}
+
+	for _, id := range next {
+		if !slices.Contains(current, id) && !principal.HasIn(rbac.PermPlacesWrite, id) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// canDeletePlace reports whether the principal may delete a place, which
+// removes it from every one of its regionals.
+func canDeletePlace(principal *rbac.Principal, current []uint64) bool {
+	if principal == nil {
+		return false
+	}
+	if principal.HasGlobal(rbac.PermPlacesWrite) {
+		return true
+	}
+
+	for _, id := range current {
+		if !principal.HasIn(rbac.PermPlacesWrite, id) {
+			return false
+		}
+	}
+
+	return len(current) > 0
+}
+
+// canReviewPlace reports whether the principal may review the changes to a
+// place in the regionals ids, and so make them without review. Reviewers
+// scoped to regionals must have all of them, and changes in none are left
+// to the unscoped ones.
+func canReviewPlace(principal *rbac.Principal, ids []uint64) bool {
+	if principal == nil {
+		return false
+	}
+	if principal.HasGlobal(rbac.PermPlacesReview) {
+		return true
+	}
+
+	for _, id := range ids {
+		if !principal.HasIn(rbac.PermPlacesReview, id) {
+			return false
+		}
+	}
+
+	return len(ids) > 0
+}
+
+// unionIDs returns the IDs in either a or b, once each.
+func unionIDs(a, b []uint64) []uint64 {
+	ids := slices.Clone(a)
+	for _, id := range b {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
 }
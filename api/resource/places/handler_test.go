@@ -0,0 +1,287 @@
+package places
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	e "cuide/api/resource/common/err"
+	"cuide/util/rbac"
+)
+
+func regional(id uint64) *uint64 {
+	return &id
+}
+
+var (
+	admin = &rbac.Principal{Assignments: []rbac.Assignment{{Role: rbac.RoleAdmin}}}
+	// editor edits the places of regional 1 and reviews those of regional 2.
+	editor = &rbac.Principal{Assignments: []rbac.Assignment{
+		{Role: rbac.RoleEditor, RegionalID: regional(1)},
+		{Role: rbac.RoleEditor, RegionalID: regional(2)},
+		{Role: rbac.RoleReviewer, RegionalID: regional(2)},
+	}}
+	apiKey = &rbac.Principal{Scopes: []rbac.Scope{rbac.ScopePlacesWrite}}
+)
+
+func TestCanEditPlace(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *rbac.Principal
+		current   []uint64
+		want      bool
+	}{
+		{"admin", admin, []uint64{3}, true},
+		{"admin without regionals", admin, nil, true},
+		{"editor in one of the regionals", editor, []uint64{1, 3}, true},
+		{"editor elsewhere", editor, []uint64{3}, false},
+		{"editor without regionals", editor, nil, false},
+		{"api key", apiKey, []uint64{3}, true},
+		{"anonymous", nil, []uint64{1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canEditPlace(tt.principal, tt.current); got != tt.want {
+				t.Errorf("canEditPlace = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanAssignRegionals(t *testing.T) {
+	tests := []struct {
+		name          string
+		principal     *rbac.Principal
+		current, next []uint64
+		want          bool
+	}{
+		{"admin", admin, nil, []uint64{3}, true},
+		{"editor to their regional", editor, nil, []uint64{1}, true},
+		{"editor to another regional", editor, nil, []uint64{1, 3}, false},
+		{"editor keeping another regional", editor, []uint64{1, 3}, []uint64{1, 3}, true},
+		{"anonymous", nil, nil, []uint64{1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canAssignRegionals(tt.principal, tt.current, tt.next); got != tt.want {
+				t.Errorf("canAssignRegionals = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanDeletePlace(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *rbac.Principal
+		current   []uint64
+		want      bool
+	}{
+		{"admin", admin, []uint64{3}, true},
+		{"editor in every regional", editor, []uint64{1, 2}, true},
+		{"editor in one of the regionals", editor, []uint64{1, 3}, false},
+		{"editor without regionals", editor, nil, false},
+		{"anonymous", nil, []uint64{1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canDeletePlace(tt.principal, tt.current); got != tt.want {
+				t.Errorf("canDeletePlace = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanReviewPlace(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *rbac.Principal
+		ids       []uint64
+		want      bool
+	}{
+		{"admin", admin, []uint64{3}, true},
+		{"admin without regionals", admin, nil, true},
+		{"reviewer of every regional", editor, []uint64{2}, true},
+		{"reviewer of one of the regionals", editor, []uint64{1, 2}, false},
+		{"editor only", editor, []uint64{1}, false},
+		{"reviewer without regionals", editor, nil, false},
+		{"api key", apiKey, []uint64{1}, false},
+		{"anonymous", nil, []uint64{1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canReviewPlace(tt.principal, tt.ids); got != tt.want {
+				t.Errorf("canReviewPlace = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnionIDs(t *testing.T) {
+	a := []uint64{1, 2}
+	got := unionIDs(a, []uint64{2, 3})
+
+	if want := []uint64{1, 2, 3}; !slices.Equal(got, want) {
+		t.Errorf("unionIDs = %v, want %v", got, want)
+	}
+	if !slices.Equal(a, []uint64{1, 2}) {
+		t.Errorf("unionIDs changed its argument to %v", a)
+	}
+}
+
+func TestParseFiltersOpenAt(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantAt  string
+		wantErr []byte
+	}{
+		{"", "", nil},
+		{"open_now=false", "", nil},
+		{"open_at=2024-05-06T10:00:00-03:00", "2024-05-06T10:00:00-03:00", nil},
+		{"open_now=maybe", "", e.RespInvalidQueryParamOpenNow},
+		{"open_at=tomorrow", "", e.RespInvalidQueryParamOpenAt},
+		{"open_now=true&open_at=2024-05-06T10:00:00-03:00", "", e.RespOpenNowWithOpenAt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			filters, errResp := parseFilters(httptest.NewRequest(http.MethodGet, "/v1/places/filter?"+tt.query, nil))
+			if !bytes.Equal(errResp, tt.wantErr) {
+				t.Fatalf("parseFilters error = %s, want %s", errResp, tt.wantErr)
+			}
+
+			var at string
+			if filters.OpenAt != nil {
+				at = filters.OpenAt.Format(time.RFC3339)
+			}
+			if tt.wantErr == nil && at != tt.wantAt {
+				t.Errorf("OpenAt = %q, want %q", at, tt.wantAt)
+			}
+		})
+	}
+
+	filters, _ := parseFilters(httptest.NewRequest(http.MethodGet, "/v1/places/filter?open_now=true", nil))
+	if filters.OpenAt == nil || time.Since(*filters.OpenAt) > time.Minute {
+		t.Errorf("OpenAt = %v, want now", filters.OpenAt)
+	}
+}
+
+func TestParseFacets(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    bool
+		wantErr []byte
+	}{
+		{"", false, nil},
+		{"facets=true", true, nil},
+		{"facets=1", true, nil},
+		{"facets=false", false, nil},
+		{"facets=all", false, e.RespInvalidQueryParamFacets},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, errResp := parseFacets(httptest.NewRequest(http.MethodGet, "/v1/places?"+tt.query, nil))
+			if got != tt.want || !bytes.Equal(errResp, tt.wantErr) {
+				t.Errorf("parseFacets = %v, %s, want %v, %s", got, errResp, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	near := Filters{Near: &Near{}}
+	search := Filters{Query: "caps infantil"}
+
+	tests := []struct {
+		name    string
+		query   string
+		filters Filters
+		want    []Sort
+		wantErr bool
+	}{
+		{"none", "", Filters{}, nil, false},
+		{"name", "sort=name", Filters{}, []Sort{{Key: SortName}}, false},
+		{"descending", "sort=-updated_at", Filters{}, []Sort{{Key: SortUpdatedAt, Desc: true}}, false},
+		{"several keys", "sort=-name,%20updated_at", Filters{}, []Sort{{Key: SortName, Desc: true}, {Key: SortUpdatedAt}}, false},
+		{"repeated param", "sort=name&sort=-updated_at", Filters{}, []Sort{{Key: SortName}, {Key: SortUpdatedAt, Desc: true}}, false},
+		{"empty keys", "sort=,name,", Filters{}, []Sort{{Key: SortName}}, false},
+		{"distance near a point", "sort=distance", near, []Sort{{Key: SortDistance}}, false},
+		{"relevance of a search", "sort=-relevance", search, []Sort{{Key: SortRelevance, Desc: true}}, false},
+		{"unknown key", "sort=rating", Filters{}, nil, true},
+		{"id", "sort=id", Filters{}, nil, true},
+		{"double prefix", "sort=--name", Filters{}, nil, true},
+		{"repeated key", "sort=name,-name", Filters{}, nil, true},
+		{"distance without a point", "sort=distance", Filters{}, nil, true},
+		{"relevance without a search", "sort=relevance", Filters{}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errResp := parseSort(httptest.NewRequest(http.MethodGet, "/v1/places?"+tt.query, nil), tt.filters)
+			if (errResp != nil) != tt.wantErr {
+				t.Fatalf("parseSort error = %s, want error %v", errResp, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseSort = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseProjection(t *testing.T) {
+	tests := []struct {
+		query      string
+		wantFields []string
+		wantExpand []string
+		wantErr    []byte
+	}{
+		{"", nil, nil, nil},
+		{"fields=name,address", []string{"address", "name"}, nil, nil},
+		{"fields=name&fields=%20segment", []string{"name", "segment"}, nil, nil},
+		{"fields=", []string{}, nil, nil},
+		{"expand=", nil, []string{}, nil},
+		{"expand=segment,regionals", nil, []string{"regionals", "segment"}, nil},
+		{"fields=rating", nil, nil, e.RespInvalidQueryParamFields},
+		{"fields=Name", nil, nil, e.RespInvalidQueryParamFields},
+		{"expand=name", nil, nil, e.RespInvalidQueryParamExpand},
+	}
+
+	keys := func(m map[string]bool) []string {
+		if m == nil {
+			return nil
+		}
+		names := make([]string, 0, len(m))
+		for name := range m {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			p, errResp := parseProjection(httptest.NewRequest(http.MethodGet, "/v1/places?"+tt.query, nil))
+			if !bytes.Equal(errResp, tt.wantErr) {
+				t.Fatalf("parseProjection error = %s, want %s", errResp, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if got := keys(p.Fields); !slices.Equal(got, tt.wantFields) || (got == nil) != (tt.wantFields == nil) {
+				t.Errorf("Fields = %q, want %q", got, tt.wantFields)
+			}
+			if got := keys(p.Expand); !slices.Equal(got, tt.wantExpand) || (got == nil) != (tt.wantExpand == nil) {
+				t.Errorf("Expand = %q, want %q", got, tt.wantExpand)
+			}
+		})
+	}
+}
@@ -1,46 +1,216 @@
 package places
 
 import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	admission_criteria "cuide/api/resource/admission-criteria"
+	attendance_types "cuide/api/resource/attendance-types"
+	referral_ways "cuide/api/resource/referral-ways"
 	"cuide/api/resource/regionals"
 	"cuide/api/resource/segments"
 	service_types "cuide/api/resource/service-types"
+	"cuide/util/maps"
 )
 
 type DTO struct {
-	ID                  uint8                     `json:"id"`
-	Name                string                    `json:"name"`
-	Address             string                    `json:"address"`
-	PhoneNumber         string                    `json:"phone_number"`
-	Website             string                    `json:"website"`
-	Observations        string                    `json:"observations"`
-	GoogleMapsLink      string                    `json:"google_maps_link"`
-	GoogleMapsEmbedLink string                    `json:"google_maps_embed_link"`
-	AdmissionCriteria   string                    `json:"admission_criteria"`
-	ReferenceWay        string                    `json:"reference_ways"`
-	AttendanceType      string                    `json:"attendance_types"`
-	ServiceType         service_types.ServiceType `json:"service_type"`
-	Segment             segments.Segment          `json:"segment"`
-	Regionals           regionals.Regionals       `json:"regionals"`
+	ID                  uint64                               `json:"id"`
+	Name                string                               `json:"name"`
+	Address             string                               `json:"address"`
+	PhoneNumber         string                               `json:"phone_number"`
+	Website             string                               `json:"website"`
+	Observations        string                               `json:"observations"`
+	GoogleMapsLink      string                               `json:"google_maps_link"`
+	GoogleMapsEmbedLink string                               `json:"google_maps_embed_link"`
+	AdmissionCriteria   admission_criteria.AdmissionCriteria `json:"admission_criteria"`
+	ReferralWays        referral_ways.ReferralWays           `json:"referral_ways"`
+	AttendanceTypes     attendance_types.AttendanceTypes     `json:"attendance_types"`
+	ServiceType         service_types.ServiceType            `json:"service_type"`
+	Segment             segments.Segment                     `json:"segment"`
+	Regionals           regionals.Regionals                  `json:"regionals"`
+	Latitude            *float64                             `json:"latitude"`
+	Longitude           *float64                             `json:"longitude"`
+	Geocode             *Geocode                             `json:"geocode"`
+	OpeningHours        *OpeningHours                        `json:"opening_hours"`
+	UpdatedAt           time.Time                            `json:"updated_at"`
+	DistanceKm          *float64                             `json:"distance_km,omitempty"`
+
+	projection *Projection
+}
+
+// Relations of a DTO that a Projection may replace by their IDs.
+const (
+	RelationServiceType = "service_type"
+	RelationSegment     = "segment"
+	RelationRegionals   = "regionals"
+)
+
+// Projection selects the DTO fields to return and which relations come
+// embedded, from the fields and expand query params.
+type Projection struct {
+	// Fields are the JSON names of the fields returned besides id, or nil
+	// for all of them.
+	Fields map[string]bool
+	// Expand are the relations returned embedded, or nil for all of them.
+	// The others are returned as service_type_id, segment_id and
+	// regional_ids.
+	Expand map[string]bool
+}
+
+type dtoField struct {
+	name      string
+	index     int
+	omitEmpty bool
+}
+
+// dtoFields are the fields of DTO in the order they are encoded.
+var dtoFields = func() []dtoField {
+	t := reflect.TypeOf(DTO{})
+
+	var fields []dtoField
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("json")
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		fields = append(fields, dtoField{
+			name:      name,
+			index:     i,
+			omitEmpty: opts == "omitempty",
+		})
+	}
+
+	return fields
+}()
+
+// isField reports whether name is the JSON name of a DTO field.
+func isField(name string) bool {
+	for _, f := range dtoFields {
+		if f.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isRelation reports whether name is a relation a Projection may expand.
+func isRelation(name string) bool {
+	switch name {
+	case RelationServiceType, RelationSegment, RelationRegionals:
+		return true
+	default:
+		return false
+	}
+}
+
+// Apply makes the dtos encode only what p selects.
+func (p *Projection) Apply(dtos ...*DTO) {
+	if p.Fields == nil && p.Expand == nil {
+		return
+	}
+
+	for _, d := range dtos {
+		d.projection = p
+	}
+}
+
+func (p *Projection) selects(name string) bool {
+	return p.Fields == nil || name == "id" || p.Fields[name]
+}
+
+func (p *Projection) expands(name string) bool {
+	return p.Expand == nil || p.Expand[name]
+}
+
+// MarshalJSON encodes every field of d or, when a Projection was applied,
+// the fields it selects, with the relations it does not expand replaced by
+// their IDs.
+func (d *DTO) MarshalJSON() ([]byte, error) {
+	type dto DTO
+	if d.projection == nil {
+		return json.Marshal((*dto)(d))
+	}
+
+	v := reflect.ValueOf(d).Elem()
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for _, f := range dtoFields {
+		if !d.projection.selects(f.name) {
+			continue
+		}
+
+		name, value := f.name, v.Field(f.index)
+		if f.omitEmpty && value.IsZero() {
+			continue
+		}
+		if isRelation(name) && !d.projection.expands(name) {
+			name, value = d.relationIDs(name)
+		}
+
+		data, err := json.Marshal(value.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// relationIDs returns the name and value replacing the relation in a DTO
+// encoded without it expanded.
+func (d *DTO) relationIDs(relation string) (string, reflect.Value) {
+	switch relation {
+	case RelationServiceType:
+		return "service_type_id", reflect.ValueOf(d.ServiceType.ID)
+	case RelationSegment:
+		return "segment_id", reflect.ValueOf(d.Segment.ID)
+	default:
+		ids := make([]uint64, len(d.Regionals))
+		for i, r := range d.Regionals {
+			ids[i] = r.ID
+		}
+		return "regional_ids", reflect.ValueOf(ids)
+	}
 }
 
 type Form struct {
-	Name                string `json:"name"                   form:"required,max=2500"`
-	Address             string `json:"address"                form:"required,max=2500"`
-	PhoneNumber         string `json:"phone_number"           form:"max=2500"`
-	Website             string `json:"website"                form:"max=2500"`
-	Observations        string `json:"observations"`
-	GoogleMapsLink      string `json:"google_maps_link"       form:"required"`
-	GoogleMapsEmbedLink string `json:"google_maps_embed_link" form:"required"`
-	AdmissionCriteria   string `json:"admission_criteria"     form:"required"`
-	ReferenceWay        string `json:"reference_ways"         form:"required"`
-	AttendanceType      string `json:"attendance_types"       form:"required"`
-	ServiceTypeID       uint   `json:"service_type_id"        form:"required,min=1"`
-	SegmentID           uint   `json:"segment_id"             form:"required,min=1"`
-	RegionalIDs         []uint `json:"regional_ids"           form:"required,min=1"`
+	Name                 string   `json:"name"                   form:"required,max=2500"`
+	Address              string   `json:"address"                form:"required,max=2500"`
+	PhoneNumber          string   `json:"phone_number"           form:"max=2500"`
+	Website              string   `json:"website"                form:"max=2500"`
+	Observations         string   `json:"observations"`
+	GoogleMapsLink       string   `json:"google_maps_link"       form:"required,google_maps_url"`
+	GoogleMapsEmbedLink  string   `json:"google_maps_embed_link" form:"omitempty,max=2500,google_maps_embed"`
+	AdmissionCriteriaIDs []uint64 `json:"admission_criteria_ids" form:"required,min=1"`
+	ReferralWayIDs       []uint64 `json:"referral_way_ids"       form:"required,min=1"`
+	AttendanceTypeIDs    []uint64 `json:"attendance_type_ids"    form:"required,min=1"`
+	ServiceTypeID        uint64   `json:"service_type_id"        form:"required,min=1"`
+	SegmentID            uint64   `json:"segment_id"             form:"required,min=1"`
+	RegionalIDs          []uint64 `json:"regional_ids"`
+	Latitude             *float64 `json:"latitude"               form:"required_with=Longitude,omitempty,latitude"`
+	Longitude            *float64 `json:"longitude"              form:"required_with=Latitude,omitempty,longitude"`
+
+	OpeningHours *OpeningHours `json:"opening_hours"`
 }
 
 type Place struct {
-	ID                  uint8
+	ID                  uint64
 	Name                string
 	Address             string
 	PhoneNumber         string
@@ -48,29 +218,196 @@ type Place struct {
 	Observations        string
 	GoogleMapsLink      string
 	GoogleMapsEmbedLink string
-	AdmissionCriteria   string
-	ReferenceWay        string
-	AttendanceType      string
+	AdmissionCriteria   admission_criteria.AdmissionCriteria
+	ReferralWays        referral_ways.ReferralWays
+	AttendanceTypes     attendance_types.AttendanceTypes
 	ServiceType         service_types.ServiceType
 	Segment             segments.Segment
 	Regionals           regionals.Regionals
+	Latitude            *float64
+	Longitude           *float64
+	// Geocode tells where Latitude and Longitude came from.
+	Geocode      *Geocode
+	OpeningHours *OpeningHours
+	UpdatedAt    time.Time
+	// DistanceKm is only set when the place was searched around a point.
+	DistanceKm *float64
 }
 
 type Places []*Place
 
+const (
+	GeocodeSourceManual   = "manual"
+	GeocodeSourceMapsLink = "maps_link"
+)
+
+type Geocode struct {
+	Source     string  `json:"source"`
+	Confidence float64 `json:"confidence"`
+}
+
+// OpeningHours is stored as is in servico.horario_funcionamento and
+// evaluated by the opening_hours_open_at SQL function.
+type OpeningHours struct {
+	Weekly WeeklyHours `json:"weekly"`
+	// Exceptions replace the weekly hours on their date, e.g. holidays.
+	Exceptions []*OpeningException `json:"exceptions" form:"omitempty,unique=Date,dive"`
+}
+
+// WeeklyHours holds the hours of each weekday. A nil day is closed.
+type WeeklyHours struct {
+	Monday    *DayHours `json:"monday"`
+	Tuesday   *DayHours `json:"tuesday"`
+	Wednesday *DayHours `json:"wednesday"`
+	Thursday  *DayHours `json:"thursday"`
+	Friday    *DayHours `json:"friday"`
+	Saturday  *DayHours `json:"saturday"`
+	Sunday    *DayHours `json:"sunday"`
+}
+
+// DayHours is open all day when Open24h is set, otherwise during each of
+// Intervals. Hours past midnight go in an interval of the next day.
+type DayHours struct {
+	Open24h   bool        `json:"open_24h"`
+	Intervals []*Interval `json:"intervals" form:"omitempty,dive"`
+}
+
+type Interval struct {
+	Opens  string `json:"opens"  form:"required,time_of_day"`
+	Closes string `json:"closes" form:"required,time_of_day,time_after=Opens"`
+}
+
+// OpeningException is closed all day when it has neither Open24h nor
+// Intervals.
+type OpeningException struct {
+	Date        string      `json:"date"        form:"required,datetime=2006-01-02"`
+	Description string      `json:"description" form:"max=255"`
+	Open24h     bool        `json:"open_24h"`
+	Intervals   []*Interval `json:"intervals"   form:"omitempty,dive"`
+}
+
 type Filters struct {
-	ServiceTypes []uint8
-	Segments     []uint8
-	Regionals    []uint8
-	Name         string
+	ServiceTypes      []uint64
+	Segments          []uint64
+	Regionals         []uint64
+	AdmissionCriteria []uint64
+	ReferralWays      []uint64
+	AttendanceTypes   []uint64
+	Name              string
+	Query             string
+	// QueryTerms is Query split into words and synonym phrases, each
+	// followed by its synonyms.
+	QueryTerms [][]string
+	// OpenAt keeps the places open at that instant, in the hours of
+	// openingHoursTimeZone.
+	OpenAt *time.Time
+	// SpontaneousDemand keeps the places with an admission criterion
+	// marked as taking walk-ins.
+	SpontaneousDemand bool
+	// Sort replaces the default order, see placesOrder.
+	Sort []Sort
+	// Near orders the results by distance to a point and, when RadiusKm is
+	// set, restricts them to a radius around it.
+	Near *Near
+	// Facets asks FilterMetadata to count the facets too, which takes a
+	// query per dimension.
+	Facets bool
+}
+
+// Sort keys of Filters.Sort. Each sorts in its natural order, reversed by
+// Desc: names A to Z, closest first, least recently updated first and most
+// relevant first.
+const (
+	SortName      = "name"
+	SortDistance  = "distance"
+	SortUpdatedAt = "updated_at"
+	SortRelevance = "relevance"
+)
+
+type Sort struct {
+	Key  string
+	Desc bool
+}
+
+type Near struct {
+	Latitude  float64
+	Longitude float64
+	RadiusKm  float64
+}
+
+type Hotline struct {
+	Name  string `json:"name"`
+	Phone string `json:"phone"`
+}
+
+type UrgentResponse struct {
+	Places   []*DTO     `json:"places"`
+	Hotlines []*Hotline `json:"hotlines,omitempty"`
+}
+
+type MapsLink struct {
+	ID   uint64
+	Link string
+}
+
+type Suggestion struct {
+	Type  string  `json:"type"`
+	ID    uint64  `json:"id"`
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
+}
+
+type Suggestions []*Suggestion
+
+// Page selects the rows of a listing after or before Cursor or, for the
+// legacy page param, after Offset rows.
+type Page struct {
+	Limit  uint64
+	Offset uint64
+	Cursor string
+}
+
+// Cursors point to the pages around a page. They are empty when there is no
+// such page.
+type Cursors struct {
+	Next string
+	Prev string
 }
 
 type PaginationMetadata struct {
 	Places   []*DTO `json:"places"`
 	Metadata struct {
-		TotalPlaces uint8 `json:"total_places"`
-		Pages       uint8 `json:"pages"`
+		TotalPlaces uint64  `json:"total_places"`
+		Pages       uint64  `json:"pages"`
+		Limit       uint64  `json:"limit"`
+		NextCursor  *string `json:"next_cursor"`
+		PrevCursor  *string `json:"prev_cursor"`
 	} `json:"metadata"`
+	Facets *Facets `json:"facets,omitempty"`
+}
+
+// Facets count, for each value of a dimension, the places that the current
+// filters would return when filtering on that value instead.
+type Facets struct {
+	ServiceTypes []*Facet `json:"service_types"`
+	Segments     []*Facet `json:"segments"`
+	Regionals    []*Facet `json:"regionals"`
+}
+
+type Facet struct {
+	ID    uint64 `json:"id"`
+	Name  string `json:"name"`
+	Count uint64 `json:"count"`
+}
+
+func (pm *PaginationMetadata) setPage(page Page, cursors Cursors) {
+	pm.Metadata.Limit = page.Limit
+	if cursors.Next != "" {
+		pm.Metadata.NextCursor = &cursors.Next
+	}
+	if cursors.Prev != "" {
+		pm.Metadata.PrevCursor = &cursors.Prev
+	}
 }
 
 func (r *Place) ToDto() *DTO {
@@ -84,12 +421,33 @@ func (r *Place) ToDto() *DTO {
 		GoogleMapsLink:      r.GoogleMapsLink,
 		GoogleMapsEmbedLink: r.GoogleMapsEmbedLink,
 		AdmissionCriteria:   r.AdmissionCriteria,
-		ReferenceWay:        r.ReferenceWay,
-		AttendanceType:      r.AttendanceType,
+		ReferralWays:        r.ReferralWays,
+		AttendanceTypes:     r.AttendanceTypes,
 		ServiceType:         r.ServiceType,
 		Segment:             r.Segment,
 		Regionals:           r.Regionals,
+		Latitude:            r.Latitude,
+		Longitude:           r.Longitude,
+		Geocode:             r.Geocode,
+		OpeningHours:        r.OpeningHours,
+		UpdatedAt:           r.UpdatedAt,
+		DistanceKm:          r.DistanceKm,
+	}
+}
+
+// deriveEmbedLink fills GoogleMapsEmbedLink, when the form left it empty,
+// with a map of the coordinates or, lacking them, of the address.
+func (r *Place) deriveEmbedLink() {
+	if r.GoogleMapsEmbedLink != "" {
+		return
 	}
+
+	if r.Latitude != nil && r.Longitude != nil {
+		r.GoogleMapsEmbedLink = maps.EmbedURLForCoordinates(*r.Latitude, *r.Longitude)
+		return
+	}
+
+	r.GoogleMapsEmbedLink = maps.EmbedURLForAddress(r.Address)
 }
 
 func (rgs Places) ToDto() []*DTO {
@@ -106,10 +464,41 @@ func (f *Form) ToModel() Place {
 	rs := make(regionals.Regionals, len(f.RegionalIDs))
 	for i, r := range f.RegionalIDs {
 		rs[i] = &regionals.Regional{
-			ID: uint8(r),
+			ID: r,
 		}
 	}
 
+	acs := make(admission_criteria.AdmissionCriteria, len(f.AdmissionCriteriaIDs))
+	for i, ac := range f.AdmissionCriteriaIDs {
+		acs[i] = &admission_criteria.AdmissionCriterion{
+			ID: ac,
+		}
+	}
+
+	rws := make(referral_ways.ReferralWays, len(f.ReferralWayIDs))
+	for i, rw := range f.ReferralWayIDs {
+		rws[i] = &referral_ways.ReferralWay{
+			ID: rw,
+		}
+	}
+
+	ats := make(attendance_types.AttendanceTypes, len(f.AttendanceTypeIDs))
+	for i, at := range f.AttendanceTypeIDs {
+		ats[i] = &attendance_types.AttendanceType{
+			ID: at,
+		}
+	}
+
+	// Coordinates sent explicitly win over the ones in the Maps link.
+	var geocode *Geocode
+	lat, lng := f.Latitude, f.Longitude
+	if lat != nil && lng != nil {
+		geocode = &Geocode{Source: GeocodeSourceManual, Confidence: 1}
+	} else if la, ln, err := maps.ParseCoordinates(f.GoogleMapsLink); err == nil {
+		lat, lng = &la, &ln
+		geocode = &Geocode{Source: GeocodeSourceMapsLink, Confidence: 1}
+	}
+
 	return Place{
 		Name:                f.Name,
 		Address:             f.Address,
@@ -117,16 +506,101 @@ func (f *Form) ToModel() Place {
 		Website:             f.Website,
 		Observations:        f.Observations,
 		GoogleMapsLink:      f.GoogleMapsLink,
-		GoogleMapsEmbedLink: f.GoogleMapsEmbedLink,
-		AdmissionCriteria:   f.AdmissionCriteria,
-		AttendanceType:      f.AttendanceType,
-		ReferenceWay:        f.ReferenceWay,
+		GoogleMapsEmbedLink: maps.EmbedSrc(f.GoogleMapsEmbedLink),
+		AdmissionCriteria:   acs,
+		ReferralWays:        rws,
+		AttendanceTypes:     ats,
 		ServiceType: service_types.ServiceType{
-			ID: uint8(f.ServiceTypeID),
+			ID: f.ServiceTypeID,
 		},
 		Segment: segments.Segment{
-			ID: uint8(f.SegmentID),
+			ID: f.SegmentID,
 		},
 		Regionals: rs,
+		Latitude:  lat,
+		Longitude: lng,
+		Geocode:   geocode,
+
+		OpeningHours: f.OpeningHours,
+	}
+}
+
+// Actions of a Change.
+const (
+	ChangeCreate = "create"
+	ChangeUpdate = "update"
+	ChangeDelete = "delete"
+)
+
+// Statuses of a Change.
+const (
+	ChangePending  = "pending"
+	ChangeApproved = "approved"
+	ChangeRejected = "rejected"
+)
+
+type ChangeDTO struct {
+	ID          uint64     `json:"id"`
+	PlaceID     *uint64    `json:"place_id"`
+	Action      string     `json:"action"`
+	Form        *Form      `json:"form"`
+	RegionalIDs []uint64   `json:"regional_ids"`
+	Status      string     `json:"status"`
+	Author      string     `json:"author"`
+	Reviewer    *string    `json:"reviewer"`
+	Reason      string     `json:"reason"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ReviewedAt  *time.Time `json:"reviewed_at"`
+}
+
+// ReviewForm approves or rejects a Change, with an optional reason.
+type ReviewForm struct {
+	Reason string `json:"reason" form:"max=2500"`
+}
+
+// Change is a creation, update or deletion of a place submitted by a
+// principal that may not review it, applied once a reviewer approves it.
+type Change struct {
+	ID uint64
+	// PlaceID is nil for a creation until it is approved.
+	PlaceID *uint64
+	Action  string
+	// Form is nil for a deletion.
+	Form *Form
+	// RegionalIDs are the regionals the place was and would be in.
+	RegionalIDs []uint64
+	Status      string
+	Author      string
+	Reviewer    *string
+	Reason      string
+	CreatedAt   time.Time
+	ReviewedAt  *time.Time
+}
+
+type Changes []*Change
+
+func (c *Change) ToDto() *ChangeDTO {
+	return &ChangeDTO{
+		ID:          c.ID,
+		PlaceID:     c.PlaceID,
+		Action:      c.Action,
+		Form:        c.Form,
+		RegionalIDs: c.RegionalIDs,
+		Status:      c.Status,
+		Author:      c.Author,
+		Reviewer:    c.Reviewer,
+		Reason:      c.Reason,
+		CreatedAt:   c.CreatedAt,
+		ReviewedAt:  c.ReviewedAt,
+	}
+}
+
+func (cs Changes) ToDto() []*ChangeDTO {
+	dtos := make([]*ChangeDTO, len(cs))
+
+	for i, v := range cs {
+		dtos[i] = v.ToDto()
 	}
+
+	return dtos
 }
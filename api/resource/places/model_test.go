@@ -0,0 +1,211 @@
+package places
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"cuide/api/resource/regionals"
+	"cuide/api/resource/segments"
+	service_types "cuide/api/resource/service-types"
+	validatorUtil "cuide/util/validator"
+)
+
+func TestOpeningHoursValidation(t *testing.T) {
+	v := validatorUtil.New()
+
+	day := func(intervals ...*Interval) *DayHours {
+		return &DayHours{Intervals: intervals}
+	}
+
+	tests := []struct {
+		name    string
+		hours   *OpeningHours
+		wantErr string
+	}{
+		{
+			"valid",
+			&OpeningHours{
+				Weekly: WeeklyHours{
+					Monday:   day(&Interval{Opens: "08:00", Closes: "12:00"}, &Interval{Opens: "13:00", Closes: "24:00"}),
+					Saturday: &DayHours{Open24h: true},
+				},
+				Exceptions: []*OpeningException{{Date: "2024-12-25", Description: "Natal"}},
+			},
+			"",
+		},
+		{
+			"bad time",
+			&OpeningHours{Weekly: WeeklyHours{Monday: day(&Interval{Opens: "8:00", Closes: "12:00"})}},
+			"time_of_day",
+		},
+		{
+			"past midnight",
+			&OpeningHours{Weekly: WeeklyHours{Monday: day(&Interval{Opens: "08:00", Closes: "24:30"})}},
+			"time_of_day",
+		},
+		{
+			"closes before it opens",
+			&OpeningHours{Weekly: WeeklyHours{Friday: day(&Interval{Opens: "22:00", Closes: "02:00"})}},
+			"time_after",
+		},
+		{
+			"bad date",
+			&OpeningHours{Exceptions: []*OpeningException{{Date: "25/12/2024"}}},
+			"datetime",
+		},
+		{
+			"repeated date",
+			&OpeningHours{Exceptions: []*OpeningException{{Date: "2024-12-25"}, {Date: "2024-12-25"}}},
+			"unique",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Struct(tt.hours)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Struct = %v, want nil", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), "'"+tt.wantErr+"'") {
+				t.Errorf("Struct = %v, want a %s error", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPaginationMetadataFacets(t *testing.T) {
+	var pm PaginationMetadata
+	data, err := json.Marshal(pm)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if strings.Contains(string(data), `"facets"`) {
+		t.Errorf("Marshal = %s, want no facets", data)
+	}
+
+	pm.Facets = &Facets{
+		ServiceTypes: []*Facet{{ID: 1, Name: "CAPS", Count: 4}},
+		Segments:     []*Facet{},
+		Regionals:    []*Facet{},
+	}
+	data, err = json.Marshal(pm)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `"facets":{"service_types":[{"id":1,"name":"CAPS","count":4}],"segments":[],"regionals":[]}`
+	if !strings.Contains(string(data), want) {
+		t.Errorf("Marshal = %s, want it to contain %s", data, want)
+	}
+}
+
+func TestDTOProjection(t *testing.T) {
+	distance := 1.5
+
+	newDTO := func() *DTO {
+		return &DTO{
+			ID:          7,
+			Name:        "CAPS Barreiro",
+			ServiceType: service_types.ServiceType{ID: 2, Name: "CAPS"},
+			Segment:     segments.Segment{ID: 3, Name: "Saúde"},
+			Regionals:   regionals.Regionals{{ID: 1, Name: "Barreiro"}, {ID: 4, Name: "Oeste"}},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		projection *Projection
+		distance   *float64
+		want       string
+	}{
+		{
+			"fields",
+			&Projection{Fields: map[string]bool{"name": true}},
+			nil,
+			`{"id":7,"name":"CAPS Barreiro"}`,
+		},
+		{
+			"fields keep the DTO order",
+			&Projection{Fields: map[string]bool{"segment": true, "name": true}},
+			nil,
+			`{"id":7,"name":"CAPS Barreiro","segment":{"id":3,"name":"Saúde"}}`,
+		},
+		{
+			"relations as IDs",
+			&Projection{
+				Fields: map[string]bool{"service_type": true, "segment": true, "regionals": true},
+				Expand: map[string]bool{},
+			},
+			nil,
+			`{"id":7,"service_type_id":2,"segment_id":3,"regional_ids":[1,4]}`,
+		},
+		{
+			"one relation expanded",
+			&Projection{
+				Fields: map[string]bool{"service_type": true, "regionals": true},
+				Expand: map[string]bool{"regionals": true},
+			},
+			nil,
+			`{"id":7,"service_type_id":2,"regionals":[{"id":1,"name":"Barreiro"},{"id":4,"name":"Oeste"}]}`,
+		},
+		{
+			"omitted distance",
+			&Projection{Fields: map[string]bool{"distance_km": true}},
+			nil,
+			`{"id":7}`,
+		},
+		{
+			"distance",
+			&Projection{Fields: map[string]bool{"distance_km": true}},
+			&distance,
+			`{"id":7,"distance_km":1.5}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDTO()
+			d.DistanceKm = tt.distance
+			tt.projection.Apply(d)
+
+			data, err := json.Marshal(d)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Marshal = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestDTOWithoutProjection(t *testing.T) {
+	d := &DTO{ID: 7, Regionals: regionals.Regionals{}}
+	(&Projection{}).Apply(d)
+
+	got, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	// Without a projection, every field but the omitted distance_km comes
+	// back as encoding/json writes it.
+	type dto DTO
+	want, _ := json.Marshal((*dto)(d))
+	if string(got) != string(want) {
+		t.Errorf("Marshal = %s, want %s", got, want)
+	}
+
+	for _, f := range dtoFields {
+		if !strings.Contains(string(got), `"`+f.name+`":`) && !f.omitEmpty {
+			t.Errorf("Marshal = %s, missing %s", got, f.name)
+		}
+	}
+	if strings.Contains(string(got), "distance_km") {
+		t.Errorf("Marshal = %s, want no distance_km", got)
+	}
+}
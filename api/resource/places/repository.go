@@ -2,13 +2,22 @@ package places
 
 import (
 	"context"
-	txUtil "cuide/util/db-tx"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+
+	"github.com/lib/pq"
+
+	txUtil "cuide/util/db-tx"
+	"cuide/util/query"
 )
 
+// openingHoursTimeZone is the time zone of every OpeningHours.
+const openingHoursTimeZone = "America/Sao_Paulo"
+
 type Repository struct {
 	db *sql.DB
 }
@@ -19,388 +28,617 @@ func NewRepository(db *sql.DB) *Repository {
 	}
 }
 
-func (r *Repository) List(page uint8) (Places, error) {
-	places := make([]*Place, 0)
+func (r *Repository) List(sort []Sort, page Page) (Places, Cursors, error) {
+	return r.Filter(Filters{Sort: sort}, page)
+}
 
-	rows, err := r.db.Query(`SELECT * FROM get_servicos() LIMIT 20 OFFSET $1;`, (page-1)*20)
+func (r *Repository) Create(ctx context.Context, place *Place) (*Place, error) {
+	openingHours, err := openingHoursJSON(place)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var (
-			place                                       Place
-			serviceTypeJson, segmentJson, regionalsJson string
-		)
-
-		err := rows.Scan(
-			&place.ID,
-			&place.Name,
-			&place.Address,
-			&place.PhoneNumber,
-			&place.Website,
-			&place.Observations,
-			&place.GoogleMapsLink,
-			&place.GoogleMapsEmbedLink,
-			&place.AdmissionCriteria,
-			&place.AttendanceType,
-			&place.ReferenceWay,
-			&serviceTypeJson,
-			&segmentJson,
-			&regionalsJson,
-		)
+	err = txUtil.CallTx(ctx, r.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(
+			ctx,
+			`INSERT INTO
+			public.servico (
+				tipo_servico_id,
+				nome,
+				endereco,
+				contato,
+				site,
+				observacoes,
+				eixo_id,
+				maps_link,
+				google_maps_embed_link,
+				latitude,
+				longitude,
+				geocode_source,
+				geocode_confidence,
+				geocoded_at,
+				horario_funcionamento
+			)
+		VALUES
+		(
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13,
+			CASE WHEN $12 IS NULL THEN NULL ELSE now() END,
+			$14
+		) RETURNING id;`,
+			place.ServiceType.ID,
+			place.Name,
+			place.Address,
+			place.PhoneNumber,
+			place.Website,
+			place.Observations,
+			place.Segment.ID,
+			place.GoogleMapsLink,
+			place.GoogleMapsEmbedLink,
+			place.Latitude,
+			place.Longitude,
+			geocodeSource(place),
+			geocodeConfidence(place),
+			openingHours,
+		).Scan(&place.ID)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		json.Unmarshal([]byte(serviceTypeJson), &place.ServiceType)
-		json.Unmarshal([]byte(segmentJson), &place.Segment)
-		json.Unmarshal([]byte(regionalsJson), &place.Regionals)
-
-		places = append(places, &place)
+		_, err = insertRelations(ctx, tx, place)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return places, nil
+	return place, nil
 }
 
-func (r *Repository) Create(ctx context.Context, place *Place) (*Place, error) {
-	var placeID uint8
-
-	err := r.db.QueryRow(
-		`INSERT INTO
-		public.servico (
-			tipo_servico_id,
-			nome,
-			endereco,
-			contato,
-			site,
-			observacoes,
-			eixo_id,
-			maps_link,
-			google_maps_embed_link,
-			criterios_admissao,
-			tipo_atendimento,
-			forma_encaminhamento
-		)
-	VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id;`,
-		place.ServiceType.ID,
-		place.Name,
-		place.Address,
-		place.PhoneNumber,
-		place.Website,
-		place.Observations,
-		place.Segment.ID,
-		place.GoogleMapsLink,
-		place.GoogleMapsEmbedLink,
-		place.AdmissionCriteria,
-		place.AttendanceType,
-		place.ReferenceWay,
-	).Scan(&placeID)
+func (r *Repository) Read(id uint64) (*Place, error) {
+	row := r.db.QueryRow(`SELECT * FROM get_servicos() gs WHERE gs.servico_id = $1;`, id)
+
+	return scanPlace(row)
+}
+
+// RegionalIDs returns the regionals of a place, or sql.ErrNoRows when there
+// is no such place.
+func (r *Repository) RegionalIDs(ctx context.Context, id uint64) ([]uint64, error) {
+	var ids pq.Int64Array
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT coalesce(array_agg(rs.regional_id) FILTER (WHERE rs.regional_id IS NOT NULL), '{}')
+		FROM public.servico s
+		LEFT JOIN public.regionais_servico rs ON rs.servico_id = s.id
+		WHERE s.id = $1
+		GROUP BY s.id;`,
+		id,
+	).Scan(&ids)
 	if err != nil {
 		return nil, err
 	}
 
-	stmt, err := r.db.Prepare(
-		`INSERT INTO public.regionais_servico (servico_id, regional_id) VALUES ($1, $2)`,
-	)
-	if err != nil {
-		return nil, err
+	regionalIDs := make([]uint64, len(ids))
+	for i, id := range ids {
+		regionalIDs[i] = uint64(id)
 	}
-	for _, rs := range place.Regionals {
-		_, err := stmt.Exec(placeID, rs.ID)
+
+	return regionalIDs, nil
+}
+
+func (r *Repository) Delete(id uint64) (int64, error) {
+	var rows int64
+
+	err := txUtil.CallTx(context.Background(), r.db, func(tx *sql.Tx) error {
+		if _, err := deleteRelations(context.Background(), tx, id); err != nil {
+			return err
+		}
+
+		result, err := tx.Exec("DELETE FROM public.servico WHERE id = $1;", id)
 		if err != nil {
-			return nil, err
+			return err
 		}
-	}
 
-	return place, nil
+		rows, err = result.RowsAffected()
+		return err
+	})
+
+	return rows, err
 }
 
-func (r *Repository) Read(id uint8) (*Place, error) {
-	var (
-		place                                       Place
-		serviceTypeJson, segmentJson, regionalsJson string
-	)
+// Filter returns a page of the places matching filters, with the cursors of
+// the pages around it. It returns query.ErrInvalidCursor when page.Cursor was
+// not made for the order of these filters.
+func (r *Repository) Filter(filters Filters, page Page) (Places, Cursors, error) {
+	var cursors Cursors
 
-	err := r.db.QueryRow(`
-	select
-			s.id as servico_id,
-			s.nome::text as servico_nome,
-			s.endereco::text as servico_endereco,
-			s.contato::text as servico_contato,
-			s.site::text as servico_site,
-			s.observacoes::text as servico_observacoes,
-			s.maps_link::text as servico_maps_link,
-			s.google_maps_embed_link::text as servico_maps_embed_link,
-			s.criterios_admissao::text as servico_criterios_admissao,
-			s.tipo_atendimento::text as servico_tipo_atendimento,
-			s.forma_encaminhamento::text as servico_forma_encaminhamento,
-			jsonb_build_object('id',
-			ts.id,
-			'name',
-			ts.nome) as tipo_servico,
-			jsonb_build_object('id',
-			e.id,
-			'name',
-			e.nome) as eixo,
-			jsonb_agg(distinct jsonb_build_object('id',
-			r.id,
-			'name',
-			r.nome)) as regionais
-		from
-			public.servico s
-		left join public.tipo_servico ts
-						on
-			s.tipo_servico_id = ts.id
-		left join public.eixo e
-						on
-			s.eixo_id = e.id
-		left join public.regionais_servico rs
-						on
-			s.id = rs.servico_id
-		left join public.regionais r
-						on
-			rs.regional_id = r.id
-	WHERE 
-			s.id = $1
-	GROUP BY
-			s.id, ts.id, ts.nome, e.id, e.nome
-	ORDER BY
-			s.id`, id).
-		Scan(
-			&place.ID,
-			&place.Name,
-			&place.Address,
-			&place.PhoneNumber,
-			&place.Website,
-			&place.Observations,
-			&place.GoogleMapsLink,
-			&place.GoogleMapsEmbedLink,
-			&place.AdmissionCriteria,
-			&place.AttendanceType,
-			&place.ReferenceWay,
-			&serviceTypeJson,
-			&segmentJson,
-			&regionalsJson,
-		)
+	qb := filterQuery(query.New(), filters)
+
+	distance := "NULL::double precision"
+	if filters.Near != nil {
+		distance = distanceKm(qb, filters.Near)
+	}
+
+	order := placesOrder(qb, filters, distance)
+
+	var cursor *query.Cursor
+	if page.Cursor != "" {
+		var err error
+		if cursor, err = order.DecodeCursor(page.Cursor); err != nil {
+			return nil, cursors, err
+		}
+		order.Seek(qb, cursor)
+	}
+	backward := cursor != nil && cursor.Backward
+
+	// The page is picked from servico alone, where the sort indexes of
+	// 00013 apply, and only its rows are then read from get_servicos().
+	keys := make([]string, len(order))
+	pageKeys := make([]string, len(order))
+	pageOrder := make(query.Order, len(order))
+	for i, k := range order {
+		alias := "k" + strconv.Itoa(i)
+		keys[i] = k.Expr + " AS " + alias
+		pageKeys[i] = "p." + alias
+		pageOrder[i] = query.SortKey{Name: k.Name, Expr: pageKeys[i], Desc: k.Desc}
+	}
+
+	// One more row than asked tells whether there is a page after this one.
+	rows, err := r.db.Query(
+		`SELECT gs.*, p.distance_km, `+strings.Join(pageKeys, ", ")+`
+		FROM (
+			SELECT s.id, `+distance+` AS distance_km, `+strings.Join(keys, ", ")+`
+			FROM public.servico s`+qb.Where()+`
+			ORDER BY `+order.Clause(backward)+`
+			LIMIT `+qb.Arg(page.Limit+1)+` OFFSET `+qb.Arg(page.Offset)+`
+		) p
+		JOIN get_servicos() gs ON gs.servico_id = p.id
+		ORDER BY `+pageOrder.Clause(backward)+`;`,
+		qb.Args()...,
+	)
 	if err != nil {
-		return nil, err
+		return nil, cursors, err
 	}
+	defer rows.Close()
 
-	json.Unmarshal([]byte(serviceTypeJson), &place.ServiceType)
-	json.Unmarshal([]byte(segmentJson), &place.Segment)
-	json.Unmarshal([]byte(regionalsJson), &place.Regionals)
+	places := make([]*Place, 0)
+	placeKeys := make([][]any, 0)
+	for rows.Next() {
+		var distanceKm *float64
+		values := make([]any, len(order))
 
-	return &place, nil
+		extra := []any{&distanceKm}
+		for i := range values {
+			extra = append(extra, &values[i])
+		}
+
+		place, err := scanPlace(rows, extra...)
+		if err != nil {
+			return nil, cursors, err
+		}
+		place.DistanceKm = distanceKm
+
+		places = append(places, place)
+		placeKeys = append(placeKeys, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, cursors, err
+	}
+
+	more := uint64(len(places)) > page.Limit
+	if more {
+		places, placeKeys = places[:page.Limit], placeKeys[:page.Limit]
+	}
+	if backward {
+		slices.Reverse(places)
+		slices.Reverse(placeKeys)
+	}
+	if len(places) == 0 {
+		return places, cursors, nil
+	}
+
+	first, last := placeKeys[0], placeKeys[len(placeKeys)-1]
+	if (!backward && more) || (cursor != nil && backward) {
+		cursors.Next = order.NewCursor(last, false).Encode()
+	}
+	if (backward && more) || (cursor != nil && !backward) || page.Offset > 0 {
+		cursors.Prev = order.NewCursor(first, true).Encode()
+	}
+
+	return places, cursors, nil
 }
 
-func (r *Repository) Delete(id uint8) (int64, error) {
-	var rows int64
+// placesOrder returns the order of Filter. Without filters.Sort, it is
+// closest first when near a point, then most relevant first when searching.
+// The id always comes last to break ties.
+func placesOrder(qb *query.Builder, filters Filters, distance string) query.Order {
+	sorts := filters.Sort
+	if len(sorts) == 0 {
+		if filters.Near != nil {
+			sorts = append(sorts, Sort{Key: SortDistance})
+		}
+		if len(filters.QueryTerms) > 0 {
+			sorts = append(sorts, Sort{Key: SortRelevance})
+		}
+	}
 
-	err := txUtil.CallTx(context.Background(), r.db, func(tx *sql.Tx) error {
-		result, err := r.db.Exec("DELETE FROM public.regionais_servico WHERE servico_id = $1;", id)
-		if err != nil {
-			return err
+	order := make(query.Order, 0, len(sorts)+1)
+	for _, sort := range sorts {
+		key := query.SortKey{
+			Name: sort.Key,
+			Desc: sort.Desc,
 		}
 
-		rws, err := result.RowsAffected()
-		if err != nil {
-			return err
+		switch sort.Key {
+		case SortName:
+			key.Expr = "f_unaccent(lower(s.nome))"
+			key.Kind = query.KindText
+		case SortDistance:
+			key.Expr = "coalesce(" + distance + ", 'Infinity'::double precision)"
+			key.Kind = query.KindFloat
+		case SortUpdatedAt:
+			key.Expr = "s.updated_at"
+			key.Kind = query.KindTime
+		case SortRelevance:
+			key.Expr = "coalesce(ts_rank(s.search_vector, " + tsQuery(qb, filters.QueryTerms) + "), 0)::double precision"
+			key.Desc = !sort.Desc
+			key.Kind = query.KindFloat
+		default:
+			continue
 		}
-		rows = rws
 
-		result, err = r.db.Exec("DELETE FROM public.servico WHERE id = $1;", id)
+		order = append(order, key)
+	}
+
+	return append(order, query.SortKey{
+		Name: "id",
+		Expr: "s.id",
+		Kind: query.KindInteger,
+	})
+}
+
+// suggestThreshold is the minimum word similarity for a suggestion. It is
+// lower than the pg_trgm default (0.6) so that short, mistyped prefixes such
+// as "cresam" still match.
+const suggestThreshold = "0.3"
+
+// Suggest returns the place, segment, service type and regional names most
+// similar to q, best first.
+func (r *Repository) Suggest(ctx context.Context, q string, limit int) (Suggestions, error) {
+	suggestions := make(Suggestions, 0)
+
+	err := txUtil.CallTx(ctx, r.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(
+			ctx,
+			"SELECT set_config('pg_trgm.word_similarity_threshold', $1, true);",
+			suggestThreshold,
+		)
 		if err != nil {
 			return err
 		}
 
-		rws, err = result.RowsAffected()
+		rows, err := tx.QueryContext(
+			ctx,
+			`WITH q AS (SELECT f_unaccent(lower($1)) AS term)
+			SELECT t.type, t.id, t.name, t.score FROM (
+				SELECT 'place' AS type, s.id, s.nome AS name,
+					word_similarity(q.term, f_unaccent(lower(s.nome))) AS score
+				FROM public.servico s, q
+				WHERE q.term <% f_unaccent(lower(s.nome))
+				UNION ALL
+				SELECT 'segment', e.id, e.nome,
+					word_similarity(q.term, f_unaccent(lower(e.nome)))
+				FROM public.eixo e, q
+				WHERE q.term <% f_unaccent(lower(e.nome))
+				UNION ALL
+				SELECT 'service_type', ts.id, ts.nome,
+					word_similarity(q.term, f_unaccent(lower(ts.nome)))
+				FROM public.tipo_servico ts, q
+				WHERE q.term <% f_unaccent(lower(ts.nome))
+				UNION ALL
+				SELECT 'regional', r.id, r.nome,
+					word_similarity(q.term, f_unaccent(lower(r.nome)))
+				FROM public.regionais r, q
+				WHERE q.term <% f_unaccent(lower(r.nome))
+			) t
+			ORDER BY t.score DESC, t.name
+			LIMIT $2;`,
+			q,
+			limit,
+		)
 		if err != nil {
 			return err
 		}
-		rows = rws
+		defer rows.Close()
 
-		return nil
+		for rows.Next() {
+			var suggestion Suggestion
+			err := rows.Scan(&suggestion.Type, &suggestion.ID, &suggestion.Name, &suggestion.Score)
+			if err != nil {
+				return err
+			}
+
+			suggestions = append(suggestions, &suggestion)
+		}
+
+		return rows.Err()
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return rows, err
+	return suggestions, nil
 }
 
-func (r *Repository) Filter(filters Filters, page uint8) (Places, string, error) {
-	conditionals := ``
+// facetDimensions are the facets of FilterMetadata. Each one is counted
+// with every filter but its own, so that a facet shows how many places
+// selecting that value would return, e.g. "Barreiro (4)".
+var facetDimensions = []struct {
+	name  string
+	from  string
+	clear func(f *Filters)
+}{
+	{
+		"service_type",
+		`public.tipo_servico t
+		LEFT JOIN public.servico s ON s.tipo_servico_id = t.id AND %s`,
+		func(f *Filters) { f.ServiceTypes = nil },
+	},
+	{
+		"segment",
+		`public.eixo t
+		LEFT JOIN public.servico s ON s.eixo_id = t.id AND %s`,
+		func(f *Filters) { f.Segments = nil },
+	},
+	{
+		"regional",
+		`public.regionais t
+		LEFT JOIN public.regionais_servico j ON j.regional_id = t.id
+		LEFT JOIN public.servico s ON s.id = j.servico_id AND %s`,
+		func(f *Filters) { f.Regionals = nil },
+	},
+}
 
-	st := make([]string, len(filters.ServiceTypes))
-	for i, service_type := range filters.ServiceTypes {
-		st[i] = fmt.Sprintf("s.tipo_servico_id = %d", service_type)
+// FilterMetadata counts the places matching filters and, when
+// filters.Facets is set, their facets, in a single query.
+func (r *Repository) FilterMetadata(filters Filters, limit uint64) (pm PaginationMetadata, err error) {
+	q, args := filterMetadataQuery(filters)
+	rows, err := r.db.Query(q, args...)
+	if err != nil {
+		return pm, err
 	}
-	if len(st) > 0 {
-		conditionals += " AND "
-		conditionals += fmt.Sprintf("(%s)", strings.Join(st, " OR "))
+	defer rows.Close()
+
+	if filters.Facets {
+		pm.Facets = &Facets{
+			ServiceTypes: make([]*Facet, 0),
+			Segments:     make([]*Facet, 0),
+			Regionals:    make([]*Facet, 0),
+		}
 	}
+	for rows.Next() {
+		var (
+			dimension string
+			id        sql.NullInt64
+			name      sql.NullString
+			count     uint64
+		)
+		if err := rows.Scan(&dimension, &id, &name, &count); err != nil {
+			return pm, err
+		}
 
-	sg := make([]string, len(filters.Segments))
-	for i, segment := range filters.Segments {
-		sg[i] = fmt.Sprintf("s.eixo_id = %d", segment)
+		facet := &Facet{ID: uint64(id.Int64), Name: name.String, Count: count}
+		switch dimension {
+		case "total":
+			pm.Metadata.TotalPlaces = count
+		case "service_type":
+			pm.Facets.ServiceTypes = append(pm.Facets.ServiceTypes, facet)
+		case "segment":
+			pm.Facets.Segments = append(pm.Facets.Segments, facet)
+		case "regional":
+			pm.Facets.Regionals = append(pm.Facets.Regionals, facet)
+		}
 	}
-	if len(sg) > 0 {
-		conditionals += " AND "
-		conditionals += fmt.Sprintf("(%s)", strings.Join(sg, " OR "))
+	if err := rows.Err(); err != nil {
+		return pm, err
 	}
 
-	rg := make([]string, len(filters.Regionals))
-	for i, regional := range filters.Regionals {
-		rg[i] = fmt.Sprintf("r.id = %d", regional)
+	pm.Metadata.Pages = (pm.Metadata.TotalPlaces + limit - 1) / limit
+
+	return pm, nil
+}
+
+// filterMetadataQuery returns the query of FilterMetadata, which selects
+// rows of dimension, id, name and count. The total has a NULL id and name.
+func filterMetadataQuery(filters Filters) (string, []any) {
+	qb := filterQuery(query.New(), filters)
+
+	selects := []string{
+		`SELECT 'total', NULL::bigint, NULL::text, COUNT(s.id)
+		FROM public.servico s` + qb.Where(),
 	}
-	if len(rg) > 0 {
-		conditionals += " AND "
-		conditionals += fmt.Sprintf("(%s)", strings.Join(rg, " OR "))
+	if filters.Facets {
+		for _, dim := range facetDimensions {
+			f := filters
+			dim.clear(&f)
+			qb = filterQuery(qb.Next(), f)
+
+			selects = append(selects, fmt.Sprintf(
+				`SELECT '%s', t.id, t.nome::text, COUNT(DISTINCT s.id)
+				FROM `+dim.from+`
+				GROUP BY t.id, t.nome`,
+				dim.name,
+				qb.Expr(),
+			))
+		}
 	}
 
-	if filters.Name != "" {
-		conditionals += " AND "
-		conditionals += fmt.Sprintf(
-			" (lower(s.nome) LIKE lower('%%%s%%') OR lower(s.tipo_atendimento) LIKE lower('%%%s%%'))",
-			filters.Name,
-			filters.Name,
-		)
+	q := `SELECT * FROM (` + strings.Join(selects, " UNION ALL ") + `) f(dimension, id, name, count)
+	ORDER BY f.dimension, f.name, f.id;`
+
+	return q, qb.Args()
+}
+
+// MapsLinks lists the Google Maps link of every place, or only of the ones
+// without coordinates when missingOnly is set.
+func (r *Repository) MapsLinks(ctx context.Context, missingOnly bool) ([]*MapsLink, error) {
+	links := make([]*MapsLink, 0)
+
+	q := `SELECT id, COALESCE(maps_link, '') FROM public.servico`
+	if missingOnly {
+		q += ` WHERE latitude IS NULL`
 	}
 
-	query := fmt.Sprintf(`
-	select
-		gs.*
-	from
-		get_servicos() gs,
-		(
-			select
-				s.id as servico_id
-			from
-				public.servico s
-				left join public.tipo_servico ts on s.tipo_servico_id = ts.id
-				left join public.eixo e on s.eixo_id = e.id
-				left join public.regionais_servico rs on s.id = rs.servico_id
-				left join public.regionais r on rs.regional_id = r.id
-			where
-				1 = 1 %s
-			group by
-				s.id,
-				ts.id,
-				ts.nome,
-				e.id,
-				e.nome
-			order by
-				s.id
-		) ss
-	where
-		ss.servico_id = gs.servico_id
-	limit
-		20 offset $1;`, conditionals)
-
-	fmt.Println(query, filters)
-
-	rows, err := r.db.Query(query, (page-1)*10)
+	rows, err := r.db.QueryContext(ctx, q+` ORDER BY id;`)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 	defer rows.Close()
 
-	places := make([]*Place, 0)
 	for rows.Next() {
-		var (
-			place                                       Place
-			serviceTypeJson, segmentJson, regionalsJson string
+		link := &MapsLink{}
+		if err := rows.Scan(&link.ID, &link.Link); err != nil {
+			return nil, err
+		}
+
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+func (r *Repository) UpdateCoordinates(ctx context.Context, id uint64, lat, lng float64, geocode *Geocode) (int64, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE public.servico
+		SET
+			latitude = $2,
+			longitude = $3,
+			geocode_source = $4,
+			geocode_confidence = $5,
+			geocoded_at = now()
+		WHERE id = $1;`,
+		id, lat, lng, geocode.Source, geocode.Confidence,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// filterQuery adds to qb the conditions over public.servico s shared by
+// Filter and FilterMetadata. Values of the same dimension are ORed, and
+// dimensions are ANDed.
+func filterQuery(qb *query.Builder, filters Filters) *query.Builder {
+	if len(filters.ServiceTypes) > 0 {
+		qb.And("s.tipo_servico_id = ANY(?)", pq.Array(filters.ServiceTypes))
+	}
+
+	if len(filters.Segments) > 0 {
+		qb.And("s.eixo_id = ANY(?)", pq.Array(filters.Segments))
+	}
+
+	// The many-to-many taxonomies are matched with EXISTS so that a place
+	// linked to several selected entries is not repeated in the results.
+	for _, rel := range []struct {
+		table, column string
+		ids           []uint64
+	}{
+		{"regionais_servico", "regional_id", filters.Regionals},
+		{"criterios_admissao_servico", "criterio_admissao_id", filters.AdmissionCriteria},
+		{"forma_encaminhamento_servico", "forma_encaminhamento_id", filters.ReferralWays},
+		{"tipo_atendimento_servico", "tipo_atendimento_id", filters.AttendanceTypes},
+	} {
+		if len(rel.ids) == 0 {
+			continue
+		}
+
+		qb.And(
+			fmt.Sprintf(
+				"EXISTS (SELECT 1 FROM public.%s j WHERE j.servico_id = s.id AND j.%s = ANY(?))",
+				rel.table,
+				rel.column,
+			),
+			pq.Array(rel.ids),
 		)
+	}
 
-		err := rows.Scan(
-			&place.ID,
-			&place.Name,
-			&place.Address,
-			&place.PhoneNumber,
-			&place.Website,
-			&place.Observations,
-			&place.GoogleMapsLink,
-			&place.GoogleMapsEmbedLink,
-			&place.AdmissionCriteria,
-			&place.AttendanceType,
-			&place.ReferenceWay,
-			&serviceTypeJson,
-			&segmentJson,
-			&regionalsJson,
+	if filters.Name != "" {
+		name := query.Contains(filters.Name)
+		qb.And(
+			`lower(s.nome) LIKE lower(?) OR EXISTS (
+				SELECT 1 FROM public.tipo_atendimento_servico tas
+				JOIN public.tipo_atendimento ta ON tas.tipo_atendimento_id = ta.id
+				WHERE tas.servico_id = s.id AND lower(ta.nome) LIKE lower(?)
+			)`,
+			name,
+			name,
 		)
-		if err != nil {
-			return nil, "", err
-		}
+	}
 
-		json.Unmarshal([]byte(serviceTypeJson), &place.ServiceType)
-		json.Unmarshal([]byte(segmentJson), &place.Segment)
-		json.Unmarshal([]byte(regionalsJson), &place.Regionals)
+	if len(filters.QueryTerms) > 0 {
+		qb.And("s.search_vector @@ " + tsQuery(qb, filters.QueryTerms))
+	}
 
-		places = append(places, &place)
+	if filters.OpenAt != nil {
+		qb.And(
+			"opening_hours_open_at(s.horario_funcionamento, ?::timestamptz AT TIME ZONE ?)",
+			*filters.OpenAt,
+			openingHoursTimeZone,
+		)
 	}
 
-	return places, conditionals, nil
-}
+	if filters.SpontaneousDemand {
+		qb.And(`EXISTS (
+			SELECT 1 FROM public.criterios_admissao_servico cas
+			JOIN public.criterios_admissao ca ON cas.criterio_admissao_id = ca.id
+			WHERE cas.servico_id = s.id AND ca.demanda_espontanea
+		)`)
+	}
 
-func (r *Repository) PaginationMetadata() (pm PaginationMetadata, err error) {
-	err = r.db.QueryRow(`
-	SELECT 
-			COUNT(s.id) AS "total", 
-			CEIL(COUNT(s.id)::FLOAT / 20) AS "pages" 
-	FROM 
-			public.servico s;`,
-	).Scan(&pm.Metadata.TotalPlaces, &pm.Metadata.Pages)
+	if filters.Near != nil && filters.Near.RadiusKm > 0 {
+		qb.And(
+			"s.latitude IS NOT NULL AND "+distanceKm(qb, filters.Near)+" <= ?",
+			filters.Near.RadiusKm,
+		)
+	}
 
-	return
+	return qb
 }
 
-func (r *Repository) FilterPaginationMetadata(filters string) (pm PaginationMetadata, err error) {
-	query := fmt.Sprintf(`
-	select
-		COUNT(f.servico_id) as "total", 
-		ceil(COUNT(f.servico_id)::FLOAT / 20) as "pages"
-	from
-		(
-		select
-			gs.*
-		from
-			get_servicos() gs,
-			(
-			select
-				s.id as servico_id
-			from
-				public.servico s
-			left join public.tipo_servico ts on
-				s.tipo_servico_id = ts.id
-			left join public.eixo e on
-				s.eixo_id = e.id
-			left join public.regionais_servico rs on
-				s.id = rs.servico_id
-			left join public.regionais r on
-				rs.regional_id = r.id
-			where
-				1 = 1 %s
-			group by
-				s.id,
-				ts.id,
-				ts.nome,
-				e.id,
-				e.nome
-			order by
-				s.id
-									) ss
-		where
-			ss.servico_id = gs.servico_id) f`, filters,
+// distanceKm returns the haversine distance between near and the place s.
+func distanceKm(qb *query.Builder, near *Near) string {
+	return fmt.Sprintf(
+		"haversine_km(%s, %s, s.latitude, s.longitude)",
+		qb.Arg(near.Latitude),
+		qb.Arg(near.Longitude),
 	)
+}
+
+// tsQuery builds a tsquery that requires every word or phrase of terms,
+// where each matches itself or any of its synonyms.
+func tsQuery(qb *query.Builder, terms [][]string) string {
+	words := make([]string, len(terms))
+	for i, alternatives := range terms {
+		ors := make([]string, len(alternatives))
+		for j, term := range alternatives {
+			ors[j] = "websearch_to_tsquery('public.pt_unaccent', " + qb.Arg(term) + ")"
+		}
 
-	err = r.db.QueryRow(query).Scan(&pm.Metadata.TotalPlaces, &pm.Metadata.Pages)
+		words[i] = "(" + strings.Join(ors, " || ") + ")"
+	}
 
-	return
+	return strings.Join(words, " && ")
 }
 
 func (r *Repository) Update(ctx context.Context, place *Place) (int64, error) {
 	var rowsAffected int64
 
+	openingHours, err := openingHoursJSON(place)
+	if err != nil {
+		return 0, err
+	}
+
 	tx, err := r.db.Begin()
 	if err != nil {
 		return 0, err
@@ -425,10 +663,13 @@ func (r *Repository) Update(ctx context.Context, place *Place) (int64, error) {
 			eixo_id = $7,
 			maps_link = $8,
 			google_maps_embed_link = $9,
-			criterios_admissao = $10,
-			tipo_atendimento = $11,
-			forma_encaminhamento = $12
-		WHERE id = $13`,
+			latitude = $10,
+			longitude = $11,
+			geocode_source = $12,
+			geocode_confidence = $13,
+			geocoded_at = CASE WHEN $12 IS NULL THEN NULL ELSE now() END,
+			horario_funcionamento = $14
+		WHERE id = $15`,
 		place.ServiceType.ID,
 		place.Name,
 		place.Address,
@@ -438,9 +679,11 @@ func (r *Repository) Update(ctx context.Context, place *Place) (int64, error) {
 		place.Segment.ID,
 		place.GoogleMapsLink,
 		place.GoogleMapsEmbedLink,
-		place.AdmissionCriteria,
-		place.AttendanceType,
-		place.ReferenceWay,
+		place.Latitude,
+		place.Longitude,
+		geocodeSource(place),
+		geocodeConfidence(place),
+		openingHours,
 		place.ID,
 	)
 	if err != nil {
@@ -453,47 +696,366 @@ func (r *Repository) Update(ctx context.Context, place *Place) (int64, error) {
 	}
 	rowsAffected += rw
 
-	result, err = tx.ExecContext(
-		ctx,
-		`DELETE FROM public.regionais_servico WHERE servico_id = $1`,
-		place.ID,
-	)
+	rw, err = deleteRelations(ctx, tx, place.ID)
 	if err != nil {
 		return 0, err
 	}
+	rowsAffected += rw
 
-	rw, err = result.RowsAffected()
+	rw, err = insertRelations(ctx, tx, place)
 	if err != nil {
 		return 0, err
 	}
 	rowsAffected += rw
 
-	stmt, err := tx.PrepareContext(
-		ctx,
-		`INSERT INTO public.regionais_servico (servico_id, regional_id) VALUES ($1, $2)`,
-	)
+	err = tx.Commit()
 	if err != nil {
 		return 0, err
 	}
-	defer stmt.Close()
 
-	for _, rs := range place.Regionals {
-		result, err = stmt.Exec(place.ID, rs.ID)
+	return rowsAffected, nil
+}
+
+// relationTables lists the join tables between servico and its taxonomies,
+// with the column that references the taxonomy.
+var relationTables = []struct {
+	table  string
+	column string
+}{
+	{"regionais_servico", "regional_id"},
+	{"criterios_admissao_servico", "criterio_admissao_id"},
+	{"forma_encaminhamento_servico", "forma_encaminhamento_id"},
+	{"tipo_atendimento_servico", "tipo_atendimento_id"},
+}
+
+func relationIDs(place *Place) [][]uint64 {
+	ids := make([][]uint64, len(relationTables))
+
+	for _, rg := range place.Regionals {
+		ids[0] = append(ids[0], rg.ID)
+	}
+	for _, ac := range place.AdmissionCriteria {
+		ids[1] = append(ids[1], ac.ID)
+	}
+	for _, rw := range place.ReferralWays {
+		ids[2] = append(ids[2], rw.ID)
+	}
+	for _, at := range place.AttendanceTypes {
+		ids[3] = append(ids[3], at.ID)
+	}
+
+	return ids
+}
+
+func insertRelations(ctx context.Context, tx *sql.Tx, place *Place) (int64, error) {
+	var rowsAffected int64
+
+	for i, ids := range relationIDs(place) {
+		stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(
+			`INSERT INTO public.%s (servico_id, %s) VALUES ($1, $2)`,
+			relationTables[i].table,
+			relationTables[i].column,
+		))
+		if err != nil {
+			return 0, err
+		}
+		defer stmt.Close()
+
+		for _, id := range ids {
+			result, err := stmt.ExecContext(ctx, place.ID, id)
+			if err != nil {
+				return 0, err
+			}
+
+			rw, err := result.RowsAffected()
+			if err != nil {
+				return 0, err
+			}
+			rowsAffected += rw
+		}
+	}
+
+	return rowsAffected, nil
+}
+
+func deleteRelations(ctx context.Context, tx *sql.Tx, placeID uint64) (int64, error) {
+	var rowsAffected int64
+
+	for _, rt := range relationTables {
+		result, err := tx.ExecContext(
+			ctx,
+			fmt.Sprintf(`DELETE FROM public.%s WHERE servico_id = $1`, rt.table),
+			placeID,
+		)
 		if err != nil {
 			return 0, err
 		}
 
-		rw, err = result.RowsAffected()
+		rw, err := result.RowsAffected()
 		if err != nil {
 			return 0, err
 		}
 		rowsAffected += rw
 	}
 
-	err = tx.Commit()
+	return rowsAffected, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanPlace reads a row in the column order returned by get_servicos(),
+// followed by any extra columns selected after them.
+func scanPlace(row scanner, extra ...any) (*Place, error) {
+	var (
+		place                                       Place
+		serviceTypeJson, segmentJson, regionalsJson string
+		admissionCriteriaJson, attendanceTypesJson  string
+		referralWaysJson                            string
+		geocodeSource                               sql.NullString
+		geocodeConfidence                           sql.NullFloat64
+		openingHoursJson                            sql.NullString
+	)
+
+	dest := []any{
+		&place.ID,
+		&place.Name,
+		&place.Address,
+		&place.PhoneNumber,
+		&place.Website,
+		&place.Observations,
+		&place.GoogleMapsLink,
+		&place.GoogleMapsEmbedLink,
+		&admissionCriteriaJson,
+		&attendanceTypesJson,
+		&referralWaysJson,
+		&serviceTypeJson,
+		&segmentJson,
+		&regionalsJson,
+		&place.Latitude,
+		&place.Longitude,
+		&geocodeSource,
+		&geocodeConfidence,
+		&openingHoursJson,
+		&place.UpdatedAt,
+	}
+
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	json.Unmarshal([]byte(admissionCriteriaJson), &place.AdmissionCriteria)
+	json.Unmarshal([]byte(attendanceTypesJson), &place.AttendanceTypes)
+	json.Unmarshal([]byte(referralWaysJson), &place.ReferralWays)
+	json.Unmarshal([]byte(serviceTypeJson), &place.ServiceType)
+	json.Unmarshal([]byte(segmentJson), &place.Segment)
+	json.Unmarshal([]byte(regionalsJson), &place.Regionals)
+
+	if geocodeSource.Valid {
+		place.Geocode = &Geocode{
+			Source:     geocodeSource.String,
+			Confidence: geocodeConfidence.Float64,
+		}
+	}
+
+	if openingHoursJson.Valid {
+		place.OpeningHours = &OpeningHours{}
+		if err := json.Unmarshal([]byte(openingHoursJson.String), place.OpeningHours); err != nil {
+			return nil, err
+		}
+	}
+
+	return &place, nil
+}
+
+func geocodeSource(place *Place) any {
+	if place.Geocode == nil {
+		return nil
+	}
+
+	return place.Geocode.Source
+}
+
+func geocodeConfidence(place *Place) any {
+	if place.Geocode == nil {
+		return nil
+	}
+
+	return place.Geocode.Confidence
+}
+
+func openingHoursJSON(place *Place) (any, error) {
+	if place.OpeningHours == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(place.OpeningHours)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+const selectChanges = `SELECT
+	id, servico_id, acao, dados, regional_ids, status, autor, revisor, motivo, created_at, reviewed_at
+FROM public.alteracao_servico`
+
+func scanChange(row scanner) (*Change, error) {
+	var (
+		c           Change
+		placeID     sql.NullInt64
+		form        []byte
+		regionalIDs pq.Int64Array
+		reviewer    sql.NullString
+		reviewedAt  sql.NullTime
+	)
+	err := row.Scan(
+		&c.ID,
+		&placeID,
+		&c.Action,
+		&form,
+		&regionalIDs,
+		&c.Status,
+		&c.Author,
+		&reviewer,
+		&c.Reason,
+		&c.CreatedAt,
+		&reviewedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if placeID.Valid {
+		id := uint64(placeID.Int64)
+		c.PlaceID = &id
+	}
+	if form != nil {
+		c.Form = &Form{}
+		if err := json.Unmarshal(form, c.Form); err != nil {
+			return nil, err
+		}
+	}
+	c.RegionalIDs = make([]uint64, len(regionalIDs))
+	for i, id := range regionalIDs {
+		c.RegionalIDs[i] = uint64(id)
+	}
+	if reviewer.Valid {
+		c.Reviewer = &reviewer.String
+	}
+	if reviewedAt.Valid {
+		c.ReviewedAt = &reviewedAt.Time
+	}
+
+	return &c, nil
+}
+
+// CreateChange stores c as pending.
+func (r *Repository) CreateChange(ctx context.Context, c *Change) (*Change, error) {
+	var form any
+	if c.Form != nil {
+		data, err := json.Marshal(c.Form)
+		if err != nil {
+			return nil, err
+		}
+		form = string(data)
+	}
+
+	regionalIDs := make(pq.Int64Array, len(c.RegionalIDs))
+	for i, id := range c.RegionalIDs {
+		regionalIDs[i] = int64(id)
+	}
+
+	err := r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO public.alteracao_servico (servico_id, acao, dados, regional_ids, autor)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, status, created_at;`,
+		c.PlaceID,
+		c.Action,
+		form,
+		regionalIDs,
+		c.Author,
+	).Scan(&c.ID, &c.Status, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// ListChanges returns the changes in status, oldest first.
+func (r *Repository) ListChanges(ctx context.Context, status string) (Changes, error) {
+	changes := make(Changes, 0)
+
+	rows, err := r.db.QueryContext(ctx, selectChanges+" WHERE status = $1 ORDER BY id;", status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanChange(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, c)
+	}
+
+	return changes, rows.Err()
+}
+
+func (r *Repository) ReadChange(ctx context.Context, id uint64) (*Change, error) {
+	return scanChange(r.db.QueryRowContext(ctx, selectChanges+" WHERE id = $1;", id))
+}
+
+// ReviewChange moves a pending change to status, on behalf of reviewer. It
+// affects no rows when the change was reviewed meanwhile, so that a change
+// is only ever applied once.
+func (r *Repository) ReviewChange(ctx context.Context, id uint64, status, reviewer, reason string) (int64, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE public.alteracao_servico
+		SET status = $1, revisor = $2, motivo = $3, reviewed_at = now()
+		WHERE id = $4 AND status = 'pending';`,
+		status,
+		reviewer,
+		reason,
+		id,
+	)
 	if err != nil {
 		return 0, err
 	}
 
-	return rowsAffected, nil
+	return result.RowsAffected()
+}
+
+// ReopenChange puts an approved change back to pending, when applying it
+// failed.
+func (r *Repository) ReopenChange(ctx context.Context, id uint64) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE public.alteracao_servico
+		SET status = 'pending', revisor = NULL, motivo = '', reviewed_at = NULL
+		WHERE id = $1 AND status = 'approved';`,
+		id,
+	)
+
+	return err
+}
+
+// SetChangePlace links an approved creation to the place it created.
+func (r *Repository) SetChangePlace(ctx context.Context, id, placeID uint64) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE public.alteracao_servico SET servico_id = $1 WHERE id = $2;",
+		placeID,
+		id,
+	)
+
+	return err
 }
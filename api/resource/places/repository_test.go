@@ -0,0 +1,30 @@
+package places
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilterMetadataQuery(t *testing.T) {
+	filters := Filters{ServiceTypes: []uint64{1}, Segments: []uint64{2}, Regionals: []uint64{3}}
+
+	q, args := filterMetadataQuery(filters)
+	if got := strings.Count(q, "UNION ALL"); got != 0 {
+		t.Errorf("UNION ALL count without facets = %d, want 0", got)
+	}
+	if len(args) != 3 {
+		t.Errorf("len(args) without facets = %d, want 3", len(args))
+	}
+
+	filters.Facets = true
+	q, args = filterMetadataQuery(filters)
+	for _, dim := range []string{"'total'", "'service_type'", "'segment'", "'regional'"} {
+		if !strings.Contains(q, "SELECT "+dim) {
+			t.Errorf("query does not select %s", dim)
+		}
+	}
+	// Each facet keeps the two filters of the other dimensions.
+	if len(args) != 3+3*2 {
+		t.Errorf("len(args) with facets = %d, want %d", len(args), 3+3*2)
+	}
+}
@@ -0,0 +1,40 @@
+package referral_ways
+
+type DTO struct {
+	ID   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+type Form struct {
+	Name string `json:"name" form:"required,max=255"`
+}
+
+type ReferralWay struct {
+	ID   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+type ReferralWays []*ReferralWay
+
+func (r *ReferralWay) ToDto() *DTO {
+	return &DTO{
+		ID:   r.ID,
+		Name: r.Name,
+	}
+}
+
+func (rgs ReferralWays) ToDto() []*DTO {
+	dtos := make([]*DTO, len(rgs))
+
+	for i, v := range rgs {
+		dtos[i] = v.ToDto()
+	}
+
+	return dtos
+}
+
+func (f *Form) ToModel() ReferralWay {
+	return ReferralWay{
+		Name: f.Name,
+	}
+}
@@ -0,0 +1,78 @@
+package referral_ways
+
+import (
+	"database/sql"
+
+	pgUtil "cuide/util/pg"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) List() (ReferralWays, error) {
+	referralWays := make([]*ReferralWay, 0)
+
+	rows, err := r.db.Query("SELECT id, nome FROM public.forma_encaminhamento;")
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var referralWay ReferralWay
+		rows.Scan(&referralWay.ID, &referralWay.Name)
+
+		referralWays = append(referralWays, &referralWay)
+	}
+
+	return referralWays, nil
+}
+
+func (r *Repository) Create(referralWay *ReferralWay) (*ReferralWay, error) {
+	err := r.db.QueryRow("INSERT INTO public.forma_encaminhamento (nome) VALUES ($1) RETURNING id;", referralWay.Name).
+		Scan(&referralWay.ID)
+	if err != nil {
+		return nil, pgUtil.ConstraintErr(err)
+	}
+
+	return referralWay, nil
+}
+
+func (r *Repository) Read(id uint64) (*ReferralWay, error) {
+	var referralWay ReferralWay
+	err := r.db.QueryRow("SELECT id, nome FROM public.forma_encaminhamento r WHERE r.id = $1;", id).
+		Scan(&referralWay.ID, &referralWay.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &referralWay, nil
+}
+
+func (r *Repository) Update(referralWay *ReferralWay) (int64, error) {
+	result, err := r.db.Exec(
+		"UPDATE public.forma_encaminhamento SET nome = $1 WHERE id= $2;",
+		referralWay.Name,
+		referralWay.ID,
+	)
+	if err != nil {
+		return 0, pgUtil.ConstraintErr(err)
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *Repository) Delete(id uint64) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM public.forma_encaminhamento WHERE id = $1;", id)
+	if err != nil {
+		return 0, pgUtil.ConstraintErr(err)
+	}
+
+	return result.RowsAffected()
+}
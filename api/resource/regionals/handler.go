@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -14,20 +15,26 @@ import (
 	e "cuide/api/resource/common/err"
 	l "cuide/api/resource/common/log"
 	ctxUtil "cuide/util/ctx"
+	"cuide/util/geo"
 	validatorUtil "cuide/util/validator"
 )
 
+// maxBoundarySize limits the GeoJSON accepted by UpdateBoundary.
+const maxBoundarySize = 5 << 20
+
 type API struct {
 	logger     *zerolog.Logger
 	validator  *validator.Validate
 	repository *Repository
 }
 
-func New(logger *zerolog.Logger, validator *validator.Validate, db *sql.DB) *API {
+// New returns the regionals API on repository, which the places API shares
+// to locate places, so that both see the same cached boundaries.
+func New(logger *zerolog.Logger, validator *validator.Validate, repository *Repository) *API {
 	return &API{
 		logger:     logger,
 		validator:  validator,
-		repository: NewRepository(db),
+		repository: repository,
 	}
 }
 
@@ -107,7 +114,7 @@ func (a *API) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	a.logger.Info().Str(l.KeyReqID, reqID).Uint8("id", regional.ID).Msg("new regional created")
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", regional.ID).Msg("new regional created")
 	w.WriteHeader(http.StatusCreated)
 }
 
@@ -127,13 +134,13 @@ func (a *API) Create(w http.ResponseWriter, r *http.Request) {
 func (a *API) Read(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 
-	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 8)
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
 		e.BadRequest(w, e.RespInvalidURLParamID)
 		return
 	}
 
-	regional, err := a.repository.Read(uint8(id))
+	regional, err := a.repository.Read(id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			w.WriteHeader(http.StatusNotFound)
@@ -171,7 +178,7 @@ func (a *API) Read(w http.ResponseWriter, r *http.Request) {
 func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 
-	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 8)
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
 		e.BadRequest(w, e.RespInvalidURLParamID)
 		return
@@ -197,7 +204,7 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	regional := form.ToModel()
-	regional.ID = uint8(id)
+	regional.ID = id
 
 	rows, err := a.repository.Update(&regional)
 	if err != nil {
@@ -210,7 +217,7 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	a.logger.Info().Str(l.KeyReqID, reqID).Uint8("id", regional.ID).Msg("regional updated")
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", regional.ID).Msg("regional updated")
 }
 
 // Delete godoc
@@ -229,13 +236,13 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 
-	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 8)
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
 		e.BadRequest(w, e.RespInvalidURLParamID)
 		return
 	}
 
-	rows, err := a.repository.Delete(uint8(id))
+	rows, err := a.repository.Delete(id)
 	if err != nil {
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
 		e.ServerError(w, e.RespDBDataRemoveFailure)
@@ -246,5 +253,150 @@ func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	a.logger.Info().Str(l.KeyReqID, reqID).Uint8("id", uint8(id)).Msg("regional deleted")
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", id).Msg("regional deleted")
+}
+
+// Locate godoc
+//
+//	@summary		Locate regional
+//	@description	Find the regional whose boundary contains a point
+//	@tags			regionals
+//	@accept			json
+//	@produce		json
+//	@param			lat	query		number	true	"Latitude"
+//	@param			lng	query		number	true	"Longitude"
+//	@success		200	{object}	DTO
+//	@failure		400	{object}	err.Error
+//	@failure		404
+//	@failure		500	{object}	err.Error
+//	@router			/regionals/locate [get]
+func (a *API) Locate(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	lat, lng, err := geo.ParseCoordinates(r.URL.Query().Get("lat"), r.URL.Query().Get("lng"))
+	if err == geo.ErrInvalidLatitude {
+		e.BadRequest(w, e.RespInvalidQueryParamLat)
+		return
+	}
+	if err != nil {
+		e.BadRequest(w, e.RespInvalidQueryParamLng)
+		return
+	}
+
+	regional, err := a.repository.Locate(lat, lng)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataAccessFailure)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(regional.ToDto()); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespJSONEncodeFailure)
+		return
+	}
+}
+
+// ReadBoundary godoc
+//
+//	@summary		Read regional boundary
+//	@description	Read the boundary of a regional as a GeoJSON MultiPolygon
+//	@tags			regionals
+//	@accept			json
+//	@produce		json
+//	@param			id	path		string	true	"Regional ID"
+//	@success		200	{object}	object
+//	@failure		400	{object}	err.Error
+//	@failure		404
+//	@failure		500	{object}	err.Error
+//	@router			/regionals/{id}/boundary [get]
+func (a *API) ReadBoundary(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		e.BadRequest(w, e.RespInvalidURLParamID)
+		return
+	}
+
+	boundary, err := a.repository.ReadBoundary(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataAccessFailure)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(boundary); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespJSONEncodeFailure)
+		return
+	}
+}
+
+// UpdateBoundary godoc
+//
+//	@summary		Update regional boundary
+//	@description	Upload the boundary of a regional as a GeoJSON Polygon or MultiPolygon, bare or in a Feature
+//	@tags			regionals
+//	@accept			json
+//	@produce		json
+//	@param			id		path	string	true	"Regional ID"
+//	@param			body	body	object	true	"GeoJSON geometry"
+//	@success		200
+//	@failure		400	{object}	err.Error
+//	@failure		404
+//	@failure		422	{object}	err.Errors
+//	@failure		500	{object}	err.Error
+//	@router			/regionals/{id}/boundary [put]
+func (a *API) UpdateBoundary(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		e.BadRequest(w, e.RespInvalidURLParamID)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBoundarySize))
+	if err != nil || !json.Valid(data) {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.BadRequest(w, e.RespJSONDecodeFailure)
+		return
+	}
+
+	boundary, err := geo.ParseBoundary(data)
+	if err != nil {
+		respBody, err := json.Marshal(validatorUtil.ErrResponse{Errors: []string{err.Error()}})
+		if err != nil {
+			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+			e.ServerError(w, e.RespJSONEncodeFailure)
+			return
+		}
+
+		e.ValidationErrors(w, respBody)
+		return
+	}
+
+	rows, err := a.repository.UpdateBoundary(id, boundary)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+		e.ServerError(w, e.RespDBDataUpdateFailure)
+		return
+	}
+	if rows == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", id).Msg("regional boundary updated")
 }
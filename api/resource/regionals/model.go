@@ -1,7 +1,7 @@
 package regionals
 
 type DTO struct {
-	ID   uint8  `json:"id"`
+	ID   uint64 `json:"id"`
 	Name string `json:"name"`
 }
 
@@ -10,7 +10,7 @@ type Form struct {
 }
 
 type Regional struct {
-	ID   uint8  `json:"id"`
+	ID   uint64 `json:"id"`
 	Name string `json:"name"`
 }
 
@@ -23,6 +23,15 @@ func (r *Regional) ToDto() *DTO {
 	}
 }
 
+func (rgs Regionals) IDs() []uint64 {
+	ids := make([]uint64, len(rgs))
+	for i, r := range rgs {
+		ids[i] = r.ID
+	}
+
+	return ids
+}
+
 func (rgs Regionals) ToDto() []*DTO {
 	dtos := make([]*DTO, len(rgs))
 
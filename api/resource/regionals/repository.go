@@ -2,15 +2,64 @@ package regionals
 
 import (
 	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"cuide/util/geo"
 )
 
+// boundaryCache holds the boundaries by regional ID, with the
+// boundary_updated_at they were read at, so that a boundary changed through
+// another Repository is read again.
+type boundaryCache struct {
+	mu      sync.RWMutex
+	entries map[uint64]cachedBoundary
+}
+
+type cachedBoundary struct {
+	updatedAt time.Time
+	boundary  *geo.Boundary
+}
+
+// get returns the boundary of the regional if it was cached at updatedAt.
+func (c *boundaryCache) get(id uint64, updatedAt time.Time) (*geo.Boundary, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[id]
+	if !ok || !entry.updatedAt.Equal(updatedAt) {
+		return nil, false
+	}
+
+	return entry.boundary, true
+}
+
+func (c *boundaryCache) put(id uint64, updatedAt time.Time, boundary *geo.Boundary) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[id] = cachedBoundary{updatedAt: updatedAt, boundary: boundary}
+}
+
+func (c *boundaryCache) remove(id uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, id)
+}
+
 type Repository struct {
 	db *sql.DB
+	// boundaries caches the parsed boundaries, so that Locate does not
+	// parse them on each lookup.
+	boundaries *boundaryCache
 }
 
 func NewRepository(db *sql.DB) *Repository {
 	return &Repository{
-		db: db,
+		db:         db,
+		boundaries: &boundaryCache{entries: make(map[uint64]cachedBoundary)},
 	}
 }
 
@@ -42,7 +91,7 @@ func (r *Repository) Create(regional *Regional) (*Regional, error) {
 	return regional, nil
 }
 
-func (r *Repository) Read(id uint8) (*Regional, error) {
+func (r *Repository) Read(id uint64) (*Regional, error) {
 	var regional Regional
 	err := r.db.QueryRow("SELECT id, nome FROM public.regionais r WHERE r.id = $1;", id).
 		Scan(&regional.ID, &regional.Name)
@@ -59,18 +108,140 @@ func (r *Repository) Update(regional *Regional) (int64, error) {
 		regional.Name,
 		regional.ID,
 	)
-	rows, err := result.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
-	return rows, err
+
+	r.boundaries.remove(regional.ID)
+
+	return result.RowsAffected()
 }
 
-func (r *Repository) Delete(id uint8) (int64, error) {
+func (r *Repository) Delete(id uint64) (int64, error) {
 	result, err := r.db.Exec("DELETE FROM public.regionais WHERE id = $1;", id)
-	rows, err := result.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
-	return rows, err
+
+	r.boundaries.remove(id)
+
+	return result.RowsAffected()
+}
+
+func (r *Repository) ReadBoundary(id uint64) (*geo.Boundary, error) {
+	var data []byte
+	err := r.db.QueryRow(
+		"SELECT boundary FROM public.regionais r WHERE r.id = $1 AND r.boundary IS NOT NULL;",
+		id,
+	).Scan(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	return geo.ParseBoundary(data)
+}
+
+// UpdateBoundary saves the boundary of the regional, which is cached as it
+// is, without being parsed again.
+func (r *Repository) UpdateBoundary(id uint64, boundary *geo.Boundary) (int64, error) {
+	data, err := json.Marshal(boundary)
+	if err != nil {
+		return 0, err
+	}
+
+	var updatedAt time.Time
+	err = r.db.QueryRow(
+		`UPDATE public.regionais SET boundary = $1, boundary_updated_at = now()
+		WHERE id = $2
+		RETURNING boundary_updated_at;`,
+		data,
+		id,
+	).Scan(&updatedAt)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	r.boundaries.put(id, updatedAt, boundary)
+	return 1, nil
+}
+
+// Locate returns the regional whose boundary contains the point, or
+// sql.ErrNoRows when there is none. Boundaries are matched in Go, in id
+// order, so the lowest id wins where two of them overlap. They are read and
+// parsed only when not cached or changed since.
+func (r *Repository) Locate(lat, lng float64) (*Regional, error) {
+	type versioned struct {
+		regional  Regional
+		updatedAt time.Time
+	}
+
+	rows, err := r.db.Query(
+		`SELECT id, nome, coalesce(boundary_updated_at, 'epoch') FROM public.regionais
+		WHERE boundary IS NOT NULL
+		ORDER BY id;`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var regionals []versioned
+	for rows.Next() {
+		var v versioned
+		if err := rows.Scan(&v.regional.ID, &v.regional.Name, &v.updatedAt); err != nil {
+			return nil, err
+		}
+
+		regionals = append(regionals, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, v := range regionals {
+		boundary, ok := r.boundaries.get(v.regional.ID, v.updatedAt)
+		if !ok {
+			boundary, err = r.readBoundaryAt(v.regional.ID, v.updatedAt)
+			if err == sql.ErrNoRows {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if boundary.Contains(lat, lng) {
+			regional := v.regional
+			return &regional, nil
+		}
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+// readBoundaryAt reads, parses and caches the boundary of the regional,
+// returning sql.ErrNoRows when it changed since updatedAt.
+func (r *Repository) readBoundaryAt(id uint64, updatedAt time.Time) (*geo.Boundary, error) {
+	var data []byte
+	err := r.db.QueryRow(
+		`SELECT boundary FROM public.regionais
+		WHERE id = $1 AND coalesce(boundary_updated_at, 'epoch') = $2;`,
+		id,
+		updatedAt,
+	).Scan(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	boundary, err := geo.ParseBoundary(data)
+	if err != nil {
+		return nil, err
+	}
+
+	r.boundaries.put(id, updatedAt, boundary)
+	return boundary, nil
 }
@@ -0,0 +1,31 @@
+package regionals
+
+import (
+	"testing"
+	"time"
+
+	"cuide/util/geo"
+)
+
+func TestBoundaryCache(t *testing.T) {
+	c := &boundaryCache{entries: make(map[uint64]cachedBoundary)}
+	updatedAt := time.Unix(1_700_000_000, 0)
+	boundary := &geo.Boundary{}
+
+	if _, ok := c.get(1, updatedAt); ok {
+		t.Error("get on an empty cache = true, want false")
+	}
+
+	c.put(1, updatedAt, boundary)
+	if got, ok := c.get(1, updatedAt); !ok || got != boundary {
+		t.Errorf("get = %p, %v, want %p, true", got, ok, boundary)
+	}
+	if _, ok := c.get(1, updatedAt.Add(time.Second)); ok {
+		t.Error("get at another boundary_updated_at = true, want false")
+	}
+
+	c.remove(1)
+	if _, ok := c.get(1, updatedAt); ok {
+		t.Error("get after remove = true, want false")
+	}
+}
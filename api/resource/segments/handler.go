@@ -107,7 +107,7 @@ func (a *API) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	a.logger.Info().Str(l.KeyReqID, reqID).Uint8("id", segment.ID).Msg("new segment created")
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", segment.ID).Msg("new segment created")
 	w.WriteHeader(http.StatusCreated)
 }
 
@@ -127,13 +127,13 @@ func (a *API) Create(w http.ResponseWriter, r *http.Request) {
 func (a *API) Read(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 
-	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 8)
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
 		e.BadRequest(w, e.RespInvalidURLParamID)
 		return
 	}
 
-	segment, err := a.repository.Read(uint8(id))
+	segment, err := a.repository.Read(id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			w.WriteHeader(http.StatusNotFound)
@@ -171,7 +171,7 @@ func (a *API) Read(w http.ResponseWriter, r *http.Request) {
 func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 
-	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 8)
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
 		e.BadRequest(w, e.RespInvalidURLParamID)
 		return
@@ -197,7 +197,7 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	segment := form.ToModel()
-	segment.ID = uint8(id)
+	segment.ID = id
 
 	rows, err := a.repository.Update(&segment)
 	if err != nil {
@@ -210,7 +210,7 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	a.logger.Info().Str(l.KeyReqID, reqID).Uint8("id", segment.ID).Msg("segment updated")
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", segment.ID).Msg("segment updated")
 }
 
 // Delete godoc
@@ -229,13 +229,13 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 
-	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 8)
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
 		e.BadRequest(w, e.RespInvalidURLParamID)
 		return
 	}
 
-	rows, err := a.repository.Delete(uint8(id))
+	rows, err := a.repository.Delete(id)
 	if err != nil {
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
 		e.ServerError(w, e.RespDBDataRemoveFailure)
@@ -246,5 +246,5 @@ func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	a.logger.Info().Str(l.KeyReqID, reqID).Uint8("id", uint8(id)).Msg("segment deleted")
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", id).Msg("segment deleted")
 }
@@ -1,7 +1,7 @@
 package segments
 
 type DTO struct {
-	ID   uint8  `json:"id"`
+	ID   uint64 `json:"id"`
 	Name string `json:"name"`
 }
 
@@ -10,7 +10,7 @@ type Form struct {
 }
 
 type Segment struct {
-	ID   uint8  `json:"id"`
+	ID   uint64 `json:"id"`
 	Name string `json:"name"`
 }
 
@@ -42,7 +42,7 @@ func (r *Repository) Create(segment *Segment) (*Segment, error) {
 	return segment, nil
 }
 
-func (r *Repository) Read(id uint8) (*Segment, error) {
+func (r *Repository) Read(id uint64) (*Segment, error) {
 	var segment Segment
 	err := r.db.QueryRow("SELECT id, nome FROM public.eixo r WHERE r.id = $1;", id).
 		Scan(&segment.ID, &segment.Name)
@@ -59,18 +59,18 @@ func (r *Repository) Update(segment *Segment) (int64, error) {
 		segment.Name,
 		segment.ID,
 	)
-	rows, err := result.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
-	return rows, err
+
+	return result.RowsAffected()
 }
 
-func (r *Repository) Delete(id uint8) (int64, error) {
+func (r *Repository) Delete(id uint64) (int64, error) {
 	result, err := r.db.Exec("DELETE FROM public.eixo WHERE id = $1;", id)
-	rows, err := result.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
-	return rows, err
+
+	return result.RowsAffected()
 }
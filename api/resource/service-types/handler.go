@@ -109,7 +109,7 @@ func (a *API) Create(w http.ResponseWriter, r *http.Request) {
 
 	a.logger.Info().
 		Str(l.KeyReqID, reqID).
-		Uint8("id", serviceType.ID).
+		Uint64("id", serviceType.ID).
 		Msg("new service type created")
 	w.WriteHeader(http.StatusCreated)
 }
@@ -130,13 +130,13 @@ func (a *API) Create(w http.ResponseWriter, r *http.Request) {
 func (a *API) Read(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 
-	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 8)
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
 		e.BadRequest(w, e.RespInvalidURLParamID)
 		return
 	}
 
-	serviceType, err := a.repository.Read(uint8(id))
+	serviceType, err := a.repository.Read(id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			w.WriteHeader(http.StatusNotFound)
@@ -174,7 +174,7 @@ func (a *API) Read(w http.ResponseWriter, r *http.Request) {
 func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 
-	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 8)
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
 		e.BadRequest(w, e.RespInvalidURLParamID)
 		return
@@ -200,7 +200,7 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	serviceType := form.ToModel()
-	serviceType.ID = uint8(id)
+	serviceType.ID = id
 
 	rows, err := a.repository.Update(&serviceType)
 	if err != nil {
@@ -213,7 +213,7 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	a.logger.Info().Str(l.KeyReqID, reqID).Uint8("id", serviceType.ID).Msg("service type updated")
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", serviceType.ID).Msg("service type updated")
 }
 
 // Delete godoc
@@ -232,13 +232,13 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 
-	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 8)
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
 		e.BadRequest(w, e.RespInvalidURLParamID)
 		return
 	}
 
-	rows, err := a.repository.Delete(uint8(id))
+	rows, err := a.repository.Delete(id)
 	if err != nil {
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
 		e.ServerError(w, e.RespDBDataRemoveFailure)
@@ -249,5 +249,5 @@ func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	a.logger.Info().Str(l.KeyReqID, reqID).Uint8("id", uint8(id)).Msg("service type deleted")
+	a.logger.Info().Str(l.KeyReqID, reqID).Uint64("id", id).Msg("service type deleted")
 }
@@ -42,7 +42,7 @@ func (r *Repository) Create(serviceType *ServiceType) (*ServiceType, error) {
 	return serviceType, nil
 }
 
-func (r *Repository) Read(id uint8) (*ServiceType, error) {
+func (r *Repository) Read(id uint64) (*ServiceType, error) {
 	var serviceType ServiceType
 	err := r.db.QueryRow("SELECT id, nome FROM public.tipo_servico r WHERE r.id = $1;", id).
 		Scan(&serviceType.ID, &serviceType.Name)
@@ -59,18 +59,18 @@ func (r *Repository) Update(serviceType *ServiceType) (int64, error) {
 		serviceType.Name,
 		serviceType.ID,
 	)
-	rows, err := result.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
-	return rows, err
+
+	return result.RowsAffected()
 }
 
-func (r *Repository) Delete(id uint8) (int64, error) {
+func (r *Repository) Delete(id uint64) (int64, error) {
 	result, err := r.db.Exec("DELETE FROM public.tipo_servico WHERE id = $1;", id)
-	rows, err := result.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
-	return rows, err
+
+	return result.RowsAffected()
 }
@@ -0,0 +1,41 @@
+package synonyms
+
+type DTO struct {
+	ID    uint64   `json:"id"`
+	Terms []string `json:"terms"`
+}
+
+type Form struct {
+	Terms []string `json:"terms" form:"required,min=2,dive,required,max=255"`
+}
+
+// Synonym is a group of terms that are searched as equivalents.
+type Synonym struct {
+	ID    uint64   `json:"id"`
+	Terms []string `json:"terms"`
+}
+
+type Synonyms []*Synonym
+
+func (s *Synonym) ToDto() *DTO {
+	return &DTO{
+		ID:    s.ID,
+		Terms: s.Terms,
+	}
+}
+
+func (ss Synonyms) ToDto() []*DTO {
+	dtos := make([]*DTO, len(ss))
+
+	for i, v := range ss {
+		dtos[i] = v.ToDto()
+	}
+
+	return dtos
+}
+
+func (f *Form) ToModel() Synonym {
+	return Synonym{
+		Terms: f.Terms,
+	}
+}
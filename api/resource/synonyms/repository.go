@@ -0,0 +1,201 @@
+package synonyms
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) List() (Synonyms, error) {
+	synonyms := make([]*Synonym, 0)
+
+	rows, err := r.db.Query("SELECT id, termos FROM public.sinonimos ORDER BY id;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var synonym Synonym
+		if err := rows.Scan(&synonym.ID, pq.Array(&synonym.Terms)); err != nil {
+			return nil, err
+		}
+
+		synonyms = append(synonyms, &synonym)
+	}
+
+	return synonyms, nil
+}
+
+func (r *Repository) Create(synonym *Synonym) (*Synonym, error) {
+	err := r.db.QueryRow(
+		"INSERT INTO public.sinonimos (termos) VALUES ($1) RETURNING id;",
+		pq.Array(synonym.Terms),
+	).Scan(&synonym.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return synonym, nil
+}
+
+func (r *Repository) Read(id uint64) (*Synonym, error) {
+	var synonym Synonym
+	err := r.db.QueryRow("SELECT id, termos FROM public.sinonimos s WHERE s.id = $1;", id).
+		Scan(&synonym.ID, pq.Array(&synonym.Terms))
+	if err != nil {
+		return nil, err
+	}
+
+	return &synonym, nil
+}
+
+func (r *Repository) Update(synonym *Synonym) (int64, error) {
+	result, err := r.db.Exec(
+		"UPDATE public.sinonimos SET termos = $1 WHERE id = $2;",
+		pq.Array(synonym.Terms),
+		synonym.ID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *Repository) Delete(id uint64) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM public.sinonimos WHERE id = $1;", id)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// maxPhraseWords is the number of words of the longest phrase of a query
+// compared to the synonym terms.
+const maxPhraseWords = 4
+
+// phrase is a run of consecutive words of a query.
+type phrase struct {
+	start, words int
+	text         string
+}
+
+// Expand splits q into words and phrases and returns, for each of them, the
+// word or phrase itself followed by the terms of each synonym group it
+// belongs to. Every run of up to maxPhraseWords words is compared to the
+// terms, so that multi-word terms such as "saúde mental" match, and the
+// longest run matching a term wins over the words it contains. Words and
+// terms are compared after Portuguese stemming and accent removal, so
+// "crianças" expands through a group containing "criança".
+func (r *Repository) Expand(ctx context.Context, q string) ([][]string, error) {
+	words := strings.Fields(q)
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	phrases := splitPhrases(words)
+	texts := make([]string, len(phrases))
+	for i, p := range phrases {
+		texts[i] = p.text
+	}
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT p.n, t.term
+		FROM unnest($1::text[]) WITH ORDINALITY AS p(phrase, n)
+		JOIN public.sinonimos s ON EXISTS (
+			SELECT 1 FROM unnest(s.termos) AS x(term)
+			WHERE plainto_tsquery('public.pt_unaccent', x.term)::text
+				= plainto_tsquery('public.pt_unaccent', p.phrase)::text
+		)
+		CROSS JOIN LATERAL unnest(s.termos) AS t(term)
+		WHERE plainto_tsquery('public.pt_unaccent', p.phrase)::text <> ''
+		ORDER BY p.n, s.id;`,
+		pq.Array(texts),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	synonyms := make([][]string, len(phrases))
+	for rows.Next() {
+		var (
+			n    int
+			term string
+		)
+		if err := rows.Scan(&n, &term); err != nil {
+			return nil, err
+		}
+
+		synonyms[n-1] = append(synonyms[n-1], term)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return groupPhrases(phrases, synonyms, len(words)), nil
+}
+
+// splitPhrases returns every run of up to maxPhraseWords words, by the word
+// it starts at and then from the shortest.
+func splitPhrases(words []string) []phrase {
+	var phrases []phrase
+	for i := range words {
+		for n := 1; n <= maxPhraseWords && i+n <= len(words); n++ {
+			phrases = append(phrases, phrase{
+				start: i,
+				words: n,
+				text:  strings.Join(words[i:i+n], " "),
+			})
+		}
+	}
+
+	return phrases
+}
+
+// groupPhrases walks the words of a query, taking at each word the longest
+// phrase starting there that has synonyms, or else the word alone, followed
+// by its synonyms without repeats. The phrases starting at a word must be
+// ordered from the shortest, as splitPhrases returns them.
+func groupPhrases(phrases []phrase, synonyms [][]string, words int) [][]string {
+	longest := make([]int, words)
+	for i, p := range phrases {
+		if p.words == 1 || len(synonyms[i]) > 0 {
+			longest[p.start] = i
+		}
+	}
+
+	var terms [][]string
+	for w := 0; w < words; {
+		i := longest[w]
+
+		group := []string{phrases[i].text}
+		seen := map[string]bool{strings.ToLower(phrases[i].text): true}
+		for _, term := range synonyms[i] {
+			if key := strings.ToLower(term); !seen[key] {
+				seen[key] = true
+				group = append(group, term)
+			}
+		}
+
+		terms = append(terms, group)
+		w += phrases[i].words
+	}
+
+	return terms
+}
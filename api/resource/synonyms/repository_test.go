@@ -0,0 +1,114 @@
+package synonyms
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitPhrases(t *testing.T) {
+	tests := []struct {
+		q    string
+		want []phrase
+	}{
+		{"caps", []phrase{{0, 1, "caps"}}},
+		{
+			"saúde mental infantil",
+			[]phrase{
+				{0, 1, "saúde"}, {0, 2, "saúde mental"}, {0, 3, "saúde mental infantil"},
+				{1, 1, "mental"}, {1, 2, "mental infantil"},
+				{2, 1, "infantil"},
+			},
+		},
+		{
+			"centro de dia para idosos",
+			[]phrase{
+				{0, 1, "centro"}, {0, 2, "centro de"}, {0, 3, "centro de dia"}, {0, 4, "centro de dia para"},
+				{1, 1, "de"}, {1, 2, "de dia"}, {1, 3, "de dia para"}, {1, 4, "de dia para idosos"},
+				{2, 1, "dia"}, {2, 2, "dia para"}, {2, 3, "dia para idosos"},
+				{3, 1, "para"}, {3, 2, "para idosos"},
+				{4, 1, "idosos"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.q, func(t *testing.T) {
+			if got := splitPhrases(strings.Fields(tt.q)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitPhrases = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupPhrases(t *testing.T) {
+	tests := []struct {
+		name     string
+		phrases  []phrase
+		synonyms [][]string
+		words    int
+		want     [][]string
+	}{
+		{
+			"word",
+			[]phrase{{0, 1, "caps"}},
+			[][]string{{"CAPS", "centro de atenção psicossocial"}},
+			1,
+			[][]string{{"caps", "centro de atenção psicossocial"}},
+		},
+		{
+			"two word term",
+			[]phrase{
+				{0, 1, "saúde"}, {0, 2, "saúde mental"}, {0, 3, "saúde mental infantil"},
+				{1, 1, "mental"}, {1, 2, "mental infantil"},
+				{2, 1, "infantil"},
+			},
+			[][]string{
+				{"saúde", "bem-estar"}, {"saúde mental", "psicologia", "Psicologia"}, nil,
+				nil, nil,
+				nil,
+			},
+			3,
+			[][]string{{"saúde mental", "psicologia"}, {"infantil"}},
+		},
+		{
+			"no longer term",
+			[]phrase{{0, 1, "saúde"}, {0, 2, "saúde infantil"}, {1, 1, "infantil"}},
+			[][]string{{"saúde", "bem-estar"}, nil, nil},
+			2,
+			[][]string{{"saúde", "bem-estar"}, {"infantil"}},
+		},
+		{
+			"three word term",
+			[]phrase{
+				{0, 1, "centro"}, {0, 2, "centro de"}, {0, 3, "centro de dia"},
+				{1, 1, "de"}, {1, 2, "de dia"},
+				{2, 1, "dia"},
+				{3, 1, "idosos"},
+			},
+			[][]string{
+				nil, nil, {"centro de dia", "hospital-dia"},
+				nil, nil,
+				nil,
+				nil,
+			},
+			4,
+			[][]string{{"centro de dia", "hospital-dia"}, {"idosos"}},
+		},
+		{
+			"no synonyms",
+			[]phrase{{0, 1, "centro"}, {0, 2, "centro de"}, {1, 1, "de"}},
+			[][]string{nil, nil, nil},
+			2,
+			[][]string{{"centro"}, {"de"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := groupPhrases(tt.phrases, tt.synonyms, tt.words); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupPhrases = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
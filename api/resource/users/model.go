@@ -0,0 +1,150 @@
+package users
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"cuide/util/rbac"
+)
+
+type DTO struct {
+	ID        uint64           `json:"id"`
+	Name      string           `json:"name"`
+	Email     string           `json:"email"`
+	Roles     []RoleAssignment `json:"roles"`
+	Active    bool             `json:"active"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// Form creates a user, whose Password is required.
+type Form struct {
+	Name     string           `json:"name"     form:"required,max=255"`
+	Email    string           `json:"email"    form:"required,max=255,email"`
+	Password string           `json:"password" form:"required,min=12,max_bytes=72"`
+	Roles    []RoleAssignment `json:"roles"    form:"omitempty,dive"`
+}
+
+// UpdateForm updates a user, keeping its password when Password is empty.
+type UpdateForm struct {
+	Name     string           `json:"name"     form:"required,max=255"`
+	Email    string           `json:"email"    form:"required,max=255,email"`
+	Password string           `json:"password" form:"omitempty,min=12,max_bytes=72"`
+	Roles    []RoleAssignment `json:"roles"    form:"omitempty,dive"`
+	Active   bool             `json:"active"`
+}
+
+// RoleAssignment grants a role to a user, over the places of a regional or,
+// without RegionalID, over everything. Admins can not be scoped.
+type RoleAssignment struct {
+	Role       rbac.Role `json:"role"        form:"required,oneof=admin reviewer editor"`
+	RegionalID *uint64   `json:"regional_id" form:"excluded_if=Role admin"`
+}
+
+type User struct {
+	ID           uint64
+	Name         string
+	Email        string
+	PasswordHash string
+	Roles        []RoleAssignment
+	Active       bool
+	CreatedAt    time.Time
+}
+
+type Users []*User
+
+// HashPassword returns the bcrypt hash of password.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches the hash of u.
+func (u *User) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+}
+
+func (u *User) ToDto() *DTO {
+	return &DTO{
+		ID:        u.ID,
+		Name:      u.Name,
+		Email:     u.Email,
+		Roles:     u.Roles,
+		Active:    u.Active,
+		CreatedAt: u.CreatedAt,
+	}
+}
+
+func (us Users) ToDto() []*DTO {
+	dtos := make([]*DTO, len(us))
+
+	for i, v := range us {
+		dtos[i] = v.ToDto()
+	}
+
+	return dtos
+}
+
+func (f *Form) ToModel() (User, error) {
+	hash, err := HashPassword(f.Password)
+	if err != nil {
+		return User{}, err
+	}
+
+	return User{
+		Name:         f.Name,
+		Email:        strings.TrimSpace(f.Email),
+		PasswordHash: hash,
+		Roles:        uniqueRoles(f.Roles),
+		Active:       true,
+	}, nil
+}
+
+func (f *UpdateForm) ToModel() (User, error) {
+	u := User{
+		Name:   f.Name,
+		Email:  strings.TrimSpace(f.Email),
+		Roles:  uniqueRoles(f.Roles),
+		Active: f.Active,
+	}
+
+	if f.Password != "" {
+		hash, err := HashPassword(f.Password)
+		if err != nil {
+			return User{}, err
+		}
+		u.PasswordHash = hash
+	}
+
+	return u, nil
+}
+
+// uniqueRoles drops the repeated assignments of roles.
+func uniqueRoles(roles []RoleAssignment) []RoleAssignment {
+	type key struct {
+		role       rbac.Role
+		regionalID uint64
+	}
+
+	seen := make(map[key]bool)
+	unique := make([]RoleAssignment, 0, len(roles))
+	for _, r := range roles {
+		k := key{role: r.Role}
+		if r.RegionalID != nil {
+			k.regionalID = *r.RegionalID
+		}
+		if seen[k] {
+			continue
+		}
+
+		seen[k] = true
+		unique = append(unique, r)
+	}
+
+	return unique
+}
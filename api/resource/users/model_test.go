@@ -0,0 +1,45 @@
+package users
+
+import (
+	"strings"
+	"testing"
+
+	validatorUtil "cuide/util/validator"
+)
+
+func TestFormPassword(t *testing.T) {
+	v := validatorUtil.New()
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  string
+	}{
+		{"ascii at the bcrypt limit", strings.Repeat("a", 72), ""},
+		{"ascii beyond the bcrypt limit", strings.Repeat("a", 73), "max_bytes"},
+		{"multibyte within 72 bytes", strings.Repeat("ç", 36), ""},
+		// 40 characters, but 80 bytes, of which bcrypt would ignore 8.
+		{"multibyte beyond 72 bytes", strings.Repeat("ç", 40), "max_bytes"},
+		{"short", "curta", "min"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := &Form{Name: "Ana", Email: "ana@example.com", Password: tt.password}
+			update := &UpdateForm{Name: "Ana", Email: "ana@example.com", Password: tt.password}
+
+			for _, err := range []error{v.Struct(form), v.Struct(update)} {
+				if tt.wantErr == "" {
+					if err != nil {
+						t.Errorf("Struct = %v, want nil", err)
+					}
+					continue
+				}
+
+				if err == nil || !strings.Contains(err.Error(), "'"+tt.wantErr+"'") {
+					t.Errorf("Struct = %v, want a %s error", err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/lib/pq"
+
+	"cuide/util/rbac"
+)
+
+var (
+	// ErrEmailTaken is returned when another user already has the email.
+	ErrEmailTaken = errors.New("email already taken")
+	// ErrUnknownRegional is returned when a role is scoped to a regional
+	// that does not exist.
+	ErrUnknownRegional = errors.New("unknown regional")
+)
+
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+const selectUsers = `SELECT
+	u.id, u.nome, u.email, u.senha_hash, u.ativo, u.created_at,
+	coalesce((
+		SELECT json_agg(json_build_object('role', p.papel, 'regional_id', p.regional_id) ORDER BY p.id)
+		FROM public.papel_usuario p
+		WHERE p.usuario_id = u.id
+	), '[]')
+FROM public.usuario u`
+
+func scanUser(row interface{ Scan(...any) error }) (*User, error) {
+	var (
+		u     User
+		roles []byte
+	)
+	err := row.Scan(&u.ID, &u.Name, &u.Email, &u.PasswordHash, &u.Active, &u.CreatedAt, &roles)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(roles, &u.Roles); err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+func (r *Repository) List() (Users, error) {
+	users := make(Users, 0)
+
+	rows, err := r.db.Query(selectUsers + " ORDER BY u.id;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
+
+func (r *Repository) Create(u *User) (*User, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
+		`INSERT INTO public.usuario (nome, email, senha_hash, ativo)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at;`,
+		u.Name,
+		u.Email,
+		u.PasswordHash,
+		u.Active,
+	).Scan(&u.ID, &u.CreatedAt)
+	if err != nil {
+		return nil, uniqueEmail(err)
+	}
+
+	if err := insertRoles(tx, u); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (r *Repository) Read(id uint64) (*User, error) {
+	return scanUser(r.db.QueryRow(selectUsers+" WHERE u.id = $1;", id))
+}
+
+// ReadByEmail finds a user by email, ignoring case.
+func (r *Repository) ReadByEmail(ctx context.Context, email string) (*User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, selectUsers+" WHERE lower(u.email) = lower($1);", email))
+}
+
+// Update saves u and replaces its roles, keeping the stored password hash
+// when u has none. Changing the password or deactivating the user revokes
+// its refresh tokens, ending its sessions.
+func (r *Repository) Update(u *User) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`UPDATE public.usuario
+		SET nome = $1, email = $2, senha_hash = coalesce(NULLIF($3, ''), senha_hash), ativo = $4
+		WHERE id = $5;`,
+		u.Name,
+		u.Email,
+		u.PasswordHash,
+		u.Active,
+		u.ID,
+	)
+	if err != nil {
+		return 0, uniqueEmail(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return rows, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM public.papel_usuario WHERE usuario_id = $1;", u.ID); err != nil {
+		return 0, err
+	}
+	if err := insertRoles(tx, u); err != nil {
+		return 0, err
+	}
+
+	if u.PasswordHash != "" || !u.Active {
+		_, err := tx.Exec(
+			"UPDATE public.refresh_token SET revoked_at = now() WHERE usuario_id = $1 AND revoked_at IS NULL;",
+			u.ID,
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return rows, tx.Commit()
+}
+
+func (r *Repository) Delete(id uint64) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM public.usuario WHERE id = $1;", id)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// HasAdmin reports whether an active admin exists.
+func (r *Repository) HasAdmin(ctx context.Context) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM public.papel_usuario p
+			JOIN public.usuario u ON u.id = p.usuario_id
+			WHERE p.papel = $1 AND u.ativo
+		);`,
+		rbac.RoleAdmin,
+	).Scan(&exists)
+
+	return exists, err
+}
+
+// Principal returns the roles of the active user whose ID is subject. Any
+// other subject, such as one of a token from another issuer, has none.
+func (r *Repository) Principal(ctx context.Context, subject string) (*rbac.Principal, error) {
+	principal := &rbac.Principal{Subject: subject}
+
+	id, err := strconv.ParseUint(subject, 10, 64)
+	if err != nil {
+		return principal, nil
+	}
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT p.papel, p.regional_id
+		FROM public.papel_usuario p
+		JOIN public.usuario u ON u.id = p.usuario_id
+		WHERE u.id = $1 AND u.ativo;`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			a          rbac.Assignment
+			regionalID sql.NullInt64
+		)
+		if err := rows.Scan(&a.Role, &regionalID); err != nil {
+			return nil, err
+		}
+		if regionalID.Valid {
+			id := uint64(regionalID.Int64)
+			a.RegionalID = &id
+		}
+
+		principal.Assignments = append(principal.Assignments, a)
+	}
+
+	return principal, rows.Err()
+}
+
+func insertRoles(tx *sql.Tx, u *User) error {
+	for _, role := range u.Roles {
+		_, err := tx.Exec(
+			"INSERT INTO public.papel_usuario (usuario_id, papel, regional_id) VALUES ($1, $2, $3);",
+			u.ID,
+			role.Role,
+			role.RegionalID,
+		)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return ErrUnknownRegional
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func uniqueEmail(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return ErrEmailTaken
+	}
+
+	return err
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	api_keys "cuide/api/resource/api-keys"
+	e "cuide/api/resource/common/err"
+	l "cuide/api/resource/common/log"
+	ctxUtil "cuide/util/ctx"
+	"cuide/util/rbac"
+)
+
+const (
+	apiKeyHeaderKey = "X-API-Key"
+
+	// apiKeyRatePeriod is the period of the rate limits of the keys, which
+	// are in requests per minute.
+	apiKeyRatePeriod = time.Minute
+)
+
+// APIKeyReader finds the unrevoked key that was issued as key, returning
+// sql.ErrNoRows for any other.
+type APIKeyReader interface {
+	ReadByKey(ctx context.Context, key string) (*api_keys.APIKey, error)
+}
+
+// APIKeys authenticates the requests made with an X-API-Key header, which
+// must then carry the read scope for GET, HEAD and OPTIONS requests, and
+// limits each key to its own rate with limiter. Unknown keys are limited to
+// failureRate per failureKey, checked before the key is looked up, so that
+// guessing keys can not flood the database. It stores the subject, the
+// principal and the ID of the key in the request context, so it must run
+// before Authenticate, which leaves those requests alone. Requests without
+// the header are passed on.
+func APIKeys(reader APIKeyReader, limiter *RateLimiter, failureRate Rate, failureKey RateLimitKey, logger *zerolog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(apiKeyHeaderKey)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			failures := "api-key-failures:" + failureKey(r)
+			if !limiter.allow(w, r, failures, failureRate, 0) {
+				return
+			}
+
+			apiKey, err := reader.ReadByKey(r.Context(), key)
+			if err == sql.ErrNoRows {
+				if limiter.allow(w, r, failures, failureRate, 1) {
+					e.Unauthorized(w, e.RespInvalidAPIKey)
+				}
+				return
+			}
+			if err != nil {
+				reqID := ctxUtil.RequestID(r.Context())
+				logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+				e.ServerError(w, e.RespDBDataAccessFailure)
+				return
+			}
+
+			rate := Rate{Limit: apiKey.RateLimit, Period: apiKeyRatePeriod}
+			if !limiter.allow(w, r, apiKey.Subject(), rate, 1) {
+				return
+			}
+
+			principal := apiKey.Principal()
+			if isSafeMethod(r.Method) && !principal.HasScope(rbac.ScopeRead) {
+				e.Forbidden(w, e.RespForbiddenScope)
+				return
+			}
+
+			ctx := ctxUtil.SetSubject(r.Context(), principal.Subject)
+			ctx = ctxUtil.SetPrincipal(ctx, principal)
+			ctx = ctxUtil.SetAPIKeyID(ctx, strconv.FormatUint(apiKey.ID, 10))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	api_keys "cuide/api/resource/api-keys"
+	ctxUtil "cuide/util/ctx"
+	"cuide/util/rbac"
+)
+
+type fakeAPIKeyReader struct {
+	keys  map[string]*api_keys.APIKey
+	reads int
+}
+
+func (f *fakeAPIKeyReader) ReadByKey(_ context.Context, key string) (*api_keys.APIKey, error) {
+	f.reads++
+	k, ok := f.keys[key]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return k, nil
+}
+
+func TestAPIKeys(t *testing.T) {
+	logger := zerolog.Nop()
+	reader := &fakeAPIKeyReader{keys: map[string]*api_keys.APIKey{
+		"cuide_read":  {ID: 1, Scopes: []rbac.Scope{rbac.ScopeRead}, RateLimit: 2},
+		"cuide_write": {ID: 2, Scopes: []rbac.Scope{rbac.ScopePlacesWrite}, RateLimit: 2},
+	}}
+	limiter := NewRateLimiter(NewMemoryStore(), &logger)
+
+	var subject, apiKeyID string
+	failures := Rate{Limit: 2, Period: time.Hour}
+	handler := APIKeys(reader, limiter, failures, fixedKey("1.2.3.4"), &logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject = ctxUtil.Subject(r.Context())
+		apiKeyID = ctxUtil.APIKeyID(r.Context())
+	}))
+
+	tests := []struct {
+		name        string
+		method, key string
+		wantStatus  int
+		wantSubject string
+	}{
+		{"no key", http.MethodGet, "", http.StatusOK, ""},
+		{"unknown key", http.MethodGet, "cuide_unknown", http.StatusUnauthorized, ""},
+		{"read key reads", http.MethodGet, "cuide_read", http.StatusOK, "api-key:1"},
+		{"write key reads", http.MethodGet, "cuide_write", http.StatusForbidden, ""},
+		{"write key writes", http.MethodPost, "cuide_write", http.StatusOK, "api-key:2"},
+		{"read key at its rate", http.MethodGet, "cuide_read", http.StatusOK, "api-key:1"},
+		{"read key beyond its rate", http.MethodGet, "cuide_read", http.StatusTooManyRequests, ""},
+		{"second unknown key", http.MethodGet, "cuide_guess", http.StatusUnauthorized, ""},
+		{"unknown keys beyond the failure rate", http.MethodGet, "cuide_guess", http.StatusTooManyRequests, ""},
+		{"known key beyond the failure rate", http.MethodPost, "cuide_write", http.StatusTooManyRequests, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, apiKeyID = "", ""
+
+			r := httptest.NewRequest(tt.method, "/v1/places", nil)
+			if tt.key != "" {
+				r.Header.Set(apiKeyHeaderKey, tt.key)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if subject != tt.wantSubject {
+				t.Errorf("subject = %q, want %q", subject, tt.wantSubject)
+			}
+			if (subject == "") != (apiKeyID == "") {
+				t.Errorf("api key ID = %q with subject %q", apiKeyID, subject)
+			}
+		})
+	}
+
+	// Beyond the failure rate, keys are not even looked up.
+	reads := reader.reads
+	r := httptest.NewRequest(http.MethodGet, "/v1/places", nil)
+	r.Header.Set(apiKeyHeaderKey, "cuide_guess")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	if reader.reads != reads {
+		t.Errorf("reads = %d, want %d", reader.reads, reads)
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	e "cuide/api/resource/common/err"
+	ctxUtil "cuide/util/ctx"
+	"cuide/util/jwt"
+)
+
+const (
+	authorizationHeaderKey   = "Authorization"
+	wwwAuthenticateHeaderKey = "WWW-Authenticate"
+	bearerPrefix             = "Bearer "
+)
+
+// Authenticate requires a valid bearer token on every request that may
+// change data. GET, HEAD and OPTIONS requests stay public, but a token they
+// carry must be valid too. The sub claim of the token is stored in the
+// request context. Requests already authenticated by APIKeys are passed on.
+func Authenticate(v *jwt.Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ctxUtil.Subject(r.Context()) != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := r.Header.Get(authorizationHeaderKey)
+			if header == "" && isSafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(header) < len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+				w.Header().Set(wwwAuthenticateHeaderKey, `Bearer`)
+				e.Unauthorized(w, e.RespMissingBearerToken)
+				return
+			}
+
+			claims, err := v.Verify(strings.TrimSpace(header[len(bearerPrefix):]))
+			if err != nil {
+				w.Header().Set(wwwAuthenticateHeaderKey, `Bearer error="invalid_token"`)
+				e.Unauthorized(w, e.RespInvalidBearerToken)
+				return
+			}
+
+			ctx := ctxUtil.SetSubject(r.Context(), claims.Subject)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
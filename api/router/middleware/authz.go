@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/rs/zerolog"
+
+	e "cuide/api/resource/common/err"
+	l "cuide/api/resource/common/log"
+	ctxUtil "cuide/util/ctx"
+	"cuide/util/rbac"
+)
+
+// PrincipalLoader finds the roles of the subject of a token.
+type PrincipalLoader interface {
+	Principal(ctx context.Context, subject string) (*rbac.Principal, error)
+}
+
+// Authorizer checks the permissions of the subject stored by Authenticate,
+// which must run before it.
+type Authorizer struct {
+	loader PrincipalLoader
+	logger *zerolog.Logger
+}
+
+func NewAuthorizer(loader PrincipalLoader, logger *zerolog.Logger) *Authorizer {
+	return &Authorizer{
+		loader: loader,
+		logger: logger,
+	}
+}
+
+// Require rejects the requests whose subject lacks perm in every scope.
+// Handlers of scoped resources then check the regionals with the principal
+// stored in the request context.
+func (a *Authorizer) Require(perm rbac.Permission) func(http.Handler) http.Handler {
+	return a.require(perm, false)
+}
+
+// RequireForWrites is Require for the requests that may change data only,
+// leaving GET, HEAD and OPTIONS public.
+func (a *Authorizer) RequireForWrites(perm rbac.Permission) func(http.Handler) http.Handler {
+	return a.require(perm, true)
+}
+
+func (a *Authorizer) require(perm rbac.Permission, writesOnly bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if writesOnly && isSafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			subject := ctxUtil.Subject(r.Context())
+			if subject == "" {
+				w.Header().Set(wwwAuthenticateHeaderKey, `Bearer`)
+				e.Unauthorized(w, e.RespMissingBearerToken)
+				return
+			}
+
+			principal := ctxUtil.Principal(r.Context())
+			if principal == nil {
+				var err error
+				principal, err = a.loader.Principal(r.Context(), subject)
+				if err != nil {
+					reqID := ctxUtil.RequestID(r.Context())
+					a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
+					e.ServerError(w, e.RespDBDataAccessFailure)
+					return
+				}
+			}
+
+			if !principal.Has(perm) {
+				e.Forbidden(w, e.RespForbidden)
+				return
+			}
+
+			ctx := ctxUtil.SetPrincipal(r.Context(), principal)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+const forwardedForHeaderKey = "X-Forwarded-For"
+
+// ClientIP finds the IP of the client of a request. X-Forwarded-For is only
+// believed when the request comes from a trusted proxy, and then only up to
+// the first address, from the right, that is not a trusted proxy, as
+// anything before it may have been sent by the client.
+type ClientIP struct {
+	trusted []netip.Prefix
+}
+
+func NewClientIP(trustedProxies []netip.Prefix) *ClientIP {
+	return &ClientIP{
+		trusted: trustedProxies,
+	}
+}
+
+// Of returns the IP of the client of r, or its RemoteAddr when that is not
+// an IP.
+func (c *ClientIP) Of(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return host
+	}
+	addr = addr.Unmap()
+
+	if !c.isTrusted(addr) {
+		return addr.String()
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values(forwardedForHeaderKey), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+
+		addr = hop.Unmap()
+		if !c.isTrusted(addr) {
+			break
+		}
+	}
+
+	return addr.String()
+}
+
+func (c *ClientIP) isTrusted(addr netip.Addr) bool {
+	for _, p := range c.trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
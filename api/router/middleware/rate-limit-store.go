@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryStoreSweepInterval is how often a MemoryStore forgets its full
+// buckets.
+const memoryStoreSweepInterval = time.Minute
+
+// Bucket is the token bucket of a rate limit key.
+type Bucket struct {
+	Tokens  float64
+	Updated time.Time
+	// Full is when the bucket will be full again, after which a store may
+	// forget it, as a missing bucket is a full one.
+	Full time.Time
+}
+
+// RateLimitStore keeps the buckets of a RateLimiter.
+type RateLimitStore interface {
+	// Update calls fn with the bucket of key, nil when it has none, and
+	// stores the bucket fn returns. Updates of a key must not overlap.
+	Update(ctx context.Context, key string, fn func(b *Bucket) *Bucket) error
+}
+
+// MemoryStore keeps the buckets in memory, so each instance of the API
+// limits the requests it receives on its own.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*Bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:   make(map[string]*Bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (s *MemoryStore) Update(_ context.Context, key string, fn func(b *Bucket) *Bucket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= memoryStoreSweepInterval {
+		for k, b := range s.buckets {
+			if !now.Before(b.Full) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	s.buckets[key] = fn(s.buckets[key])
+
+	return nil
+}
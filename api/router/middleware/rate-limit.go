@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	e "cuide/api/resource/common/err"
+	l "cuide/api/resource/common/log"
+	ctxUtil "cuide/util/ctx"
+)
+
+const (
+	rateLimitLimitHeaderKey     = "RateLimit-Limit"
+	rateLimitRemainingHeaderKey = "RateLimit-Remaining"
+	rateLimitResetHeaderKey     = "RateLimit-Reset"
+	rateLimitPolicyHeaderKey    = "RateLimit-Policy"
+	retryAfterHeaderKey         = "Retry-After"
+)
+
+// RateLimitHeaders are the response headers set by a RateLimiter, to be
+// exposed to browsers.
+var RateLimitHeaders = []string{
+	rateLimitLimitHeaderKey,
+	rateLimitRemainingHeaderKey,
+	rateLimitResetHeaderKey,
+	rateLimitPolicyHeaderKey,
+	retryAfterHeaderKey,
+}
+
+// Rate allows Limit requests per Period, in bursts of up to Limit requests.
+type Rate struct {
+	Limit  int
+	Period time.Duration
+}
+
+// perSecond is the number of tokens added to a bucket every second.
+func (r Rate) perSecond() float64 {
+	return float64(r.Limit) / r.Period.Seconds()
+}
+
+// RateLimitKey returns the key of the bucket of a request.
+type RateLimitKey func(r *http.Request) string
+
+// ByIP gives each client IP its own bucket.
+func ByIP(ip *ClientIP) RateLimitKey {
+	return func(r *http.Request) string {
+		return "ip:" + ip.Of(r)
+	}
+}
+
+// ByIdentity gives each authenticated subject its own bucket, wherever
+// it comes from, and the anonymous requests a bucket per client IP. It
+// needs Authenticate to run first.
+func ByIdentity(ip *ClientIP) RateLimitKey {
+	return func(r *http.Request) string {
+		if subject := ctxUtil.Subject(r.Context()); subject != "" {
+			return "sub:" + subject
+		}
+
+		return "ip:" + ip.Of(r)
+	}
+}
+
+// RateLimiter limits requests with token buckets kept in a RateLimitStore.
+type RateLimiter struct {
+	store  RateLimitStore
+	logger *zerolog.Logger
+}
+
+func NewRateLimiter(store RateLimitStore, logger *zerolog.Logger) *RateLimiter {
+	return &RateLimiter{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// Limit limits the requests of a route group to rate, with a bucket per
+// key. The name of the group keeps its buckets apart from those of other
+// groups. Requests made with an API key are left to APIKeys, which limits
+// them to the rate of the key.
+func (rl *RateLimiter) Limit(name string, rate Rate, key RateLimitKey) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ctxUtil.APIKeyID(r.Context()) != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !rl.allow(w, r, name+":"+key(r), rate, 1) {
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allow takes cost tokens from the bucket of key and sets the RateLimit
+// headers. A cost of 0 only checks that a token is left. When the bucket is
+// empty, it writes a 429 response and returns false. Requests are allowed
+// when the store fails, so that it does not take the API down with it.
+func (rl *RateLimiter) allow(w http.ResponseWriter, r *http.Request, key string, rate Rate, cost float64) bool {
+	now := time.Now()
+
+	var (
+		tokens  float64
+		allowed bool
+	)
+	err := rl.store.Update(r.Context(), key, func(b *Bucket) *Bucket {
+		tokens = float64(rate.Limit)
+		if b != nil {
+			tokens = math.Min(tokens, b.Tokens+now.Sub(b.Updated).Seconds()*rate.perSecond())
+		}
+
+		allowed = tokens >= 1
+		if allowed {
+			tokens -= cost
+		}
+
+		return &Bucket{
+			Tokens:  tokens,
+			Updated: now,
+			Full:    now.Add(secondsUntil(float64(rate.Limit)-tokens, rate)),
+		}
+	})
+	if err != nil {
+		reqID := ctxUtil.RequestID(r.Context())
+		rl.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("rate limit store failure")
+		return true
+	}
+
+	reset := secondsUntil(float64(rate.Limit)-tokens, rate)
+
+	h := w.Header()
+	h.Set(rateLimitLimitHeaderKey, strconv.Itoa(rate.Limit))
+	h.Set(rateLimitRemainingHeaderKey, strconv.Itoa(int(tokens)))
+	h.Set(rateLimitResetHeaderKey, strconv.Itoa(int(reset.Seconds())))
+	h.Set(rateLimitPolicyHeaderKey, fmt.Sprintf("%d;w=%d", rate.Limit, int(rate.Period.Seconds())))
+
+	if !allowed {
+		retryAfter := secondsUntil(1-tokens, rate)
+		h.Set(retryAfterHeaderKey, strconv.Itoa(int(retryAfter.Seconds())))
+		e.TooManyRequests(w, e.RespRateLimitExceeded)
+		return false
+	}
+
+	return true
+}
+
+// secondsUntil returns how long the bucket takes to gain tokens, rounded up
+// to whole seconds.
+func secondsUntil(tokens float64, rate Rate) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+
+	return time.Duration(math.Ceil(tokens/rate.perSecond())) * time.Second
+}
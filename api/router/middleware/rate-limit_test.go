@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	ctxUtil "cuide/util/ctx"
+)
+
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	return w
+}
+
+func limited(store RateLimitStore, name string, rate Rate, key RateLimitKey) http.Handler {
+	logger := zerolog.Nop()
+	limiter := NewRateLimiter(store, &logger)
+
+	return limiter.Limit(name, rate, key)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+}
+
+func fixedKey(key string) RateLimitKey {
+	return func(*http.Request) string { return key }
+}
+
+func TestLimitBurst(t *testing.T) {
+	h := limited(NewMemoryStore(), "default", Rate{Limit: 3, Period: time.Minute}, fixedKey("a"))
+
+	for i, remaining := range []string{"2", "1", "0"} {
+		w := serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want %d", i, w.Code, http.StatusOK)
+		}
+		if got := w.Header().Get(rateLimitRemainingHeaderKey); got != remaining {
+			t.Errorf("request %d: %s = %s, want %s", i, rateLimitRemainingHeaderKey, got, remaining)
+		}
+		if got := w.Header().Get(rateLimitLimitHeaderKey); got != "3" {
+			t.Errorf("request %d: %s = %s, want 3", i, rateLimitLimitHeaderKey, got)
+		}
+		if got := w.Header().Get(rateLimitPolicyHeaderKey); got != "3;w=60" {
+			t.Errorf("request %d: %s = %s, want 3;w=60", i, rateLimitPolicyHeaderKey, got)
+		}
+	}
+
+	w := serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	// A token comes back every 20s, the bucket is full after 60s.
+	if got := w.Header().Get(retryAfterHeaderKey); got != "20" {
+		t.Errorf("%s = %s, want 20", retryAfterHeaderKey, got)
+	}
+	if got := w.Header().Get(rateLimitResetHeaderKey); got != "60" {
+		t.Errorf("%s = %s, want 60", rateLimitResetHeaderKey, got)
+	}
+}
+
+func TestLimitRefill(t *testing.T) {
+	store := NewMemoryStore()
+	h := limited(store, "default", Rate{Limit: 3, Period: time.Minute}, fixedKey("a"))
+
+	// An empty bucket updated 30s ago has gained 1.5 tokens.
+	store.Update(context.Background(), "default:a", func(*Bucket) *Bucket {
+		return &Bucket{Tokens: 0, Updated: time.Now().Add(-30 * time.Second)}
+	})
+
+	if w := serve(h, httptest.NewRequest(http.MethodGet, "/", nil)); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := serve(h, httptest.NewRequest(http.MethodGet, "/", nil)); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestLimitKeepsKeysAndGroupsApart(t *testing.T) {
+	store := NewMemoryStore()
+	rate := Rate{Limit: 1, Period: time.Minute}
+	byHeader := func(r *http.Request) string { return r.Header.Get("X-Key") }
+	search := limited(store, "search", rate, byHeader)
+	other := limited(store, "default", rate, byHeader)
+
+	request := func(key string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Key", key)
+		return r
+	}
+
+	if w := serve(search, request("a")); w.Code != http.StatusOK {
+		t.Fatalf("first request: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := serve(search, request("a")); w.Code != http.StatusTooManyRequests {
+		t.Errorf("same key: status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w := serve(search, request("b")); w.Code != http.StatusOK {
+		t.Errorf("other key: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := serve(other, request("a")); w.Code != http.StatusOK {
+		t.Errorf("other group: status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestLimitSkipsAPIKeys(t *testing.T) {
+	h := limited(NewMemoryStore(), "default", Rate{Limit: 1, Period: time.Minute}, fixedKey("a"))
+
+	for i := 0; i < 3; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r = r.WithContext(ctxUtil.SetAPIKeyID(r.Context(), "1"))
+
+		if w := serve(h, r); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want %d", i, w.Code, http.StatusOK)
+		}
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Update(context.Context, string, func(*Bucket) *Bucket) error {
+	return errors.New("store down")
+}
+
+func TestLimitAllowsWhenTheStoreFails(t *testing.T) {
+	h := limited(failingStore{}, "default", Rate{Limit: 1, Period: time.Minute}, fixedKey("a"))
+
+	for i := 0; i < 3; i++ {
+		if w := serve(h, httptest.NewRequest(http.MethodGet, "/", nil)); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want %d", i, w.Code, http.StatusOK)
+		}
+	}
+}
+
+func TestByIdentity(t *testing.T) {
+	key := ByIdentity(NewClientIP(nil))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "203.0.113.7:1234"
+	if got := key(r); got != "ip:203.0.113.7" {
+		t.Errorf("anonymous key = %q, want ip:203.0.113.7", got)
+	}
+
+	r = r.WithContext(ctxUtil.SetSubject(r.Context(), "42"))
+	if got := key(r); got != "sub:42" {
+		t.Errorf("authenticated key = %q, want sub:42", got)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	store.lastSweep = now
+
+	ctx := context.Background()
+	store.Update(ctx, "full", func(*Bucket) *Bucket { return &Bucket{Full: now} })
+	store.Update(ctx, "filling", func(*Bucket) *Bucket { return &Bucket{Full: now.Add(time.Hour)} })
+
+	now = now.Add(memoryStoreSweepInterval)
+	store.Update(ctx, "other", func(*Bucket) *Bucket { return &Bucket{Full: now} })
+
+	if _, ok := store.buckets["full"]; ok {
+		t.Error("full bucket was kept")
+	}
+	if _, ok := store.buckets["filling"]; !ok {
+		t.Error("filling bucket was forgotten")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	ip := NewClientIP([]netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	})
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		want         string
+	}{
+		{"direct", "203.0.113.7:1234", nil, "203.0.113.7"},
+		{"untrusted forwarder", "203.0.113.7:1234", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted proxy", "10.0.0.1:1234", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"spoofed hop", "10.0.0.1:1234", []string{"192.0.2.1, 198.51.100.1"}, "198.51.100.1"},
+		{"proxy chain", "10.0.0.1:1234", []string{"198.51.100.1, 10.0.0.2"}, "198.51.100.1"},
+		{"split headers", "10.0.0.1:1234", []string{"198.51.100.1", "10.0.0.2"}, "198.51.100.1"},
+		{"invalid hop", "10.0.0.1:1234", []string{"198.51.100.1, junk, 10.0.0.2"}, "10.0.0.2"},
+		{"trusted proxy without header", "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"ipv6 proxy", "[2001:db8::1]:1234", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"ipv4 mapped", "[::ffff:203.0.113.7]:1234", nil, "203.0.113.7"},
+		{"not an ip", "pipe", nil, "pipe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwardedFor {
+				r.Header.Add(forwardedForHeaderKey, v)
+			}
+
+			if got := ip.Of(r); got != tt.want {
+				t.Errorf("Of = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
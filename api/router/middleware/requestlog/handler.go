@@ -43,6 +43,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	le := &logEntry{
 		RequestID:         ctxUtil.RequestID(r.Context()),
+		APIKeyID:          ctxUtil.APIKeyID(r.Context()),
 		ReceivedTime:      start,
 		RequestMethod:     r.Method,
 		RequestURL:        r.URL.String(),
@@ -78,6 +79,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	le.ResponseHeaderSize, le.ResponseBodySize = w2.size()
 	h.logger.Info().
 		Str("request_id", le.RequestID).
+		Str("api_key_id", le.APIKeyID).
 		Time("received_time", le.ReceivedTime).
 		Str("method", le.RequestMethod).
 		Str("url", le.RequestURL).
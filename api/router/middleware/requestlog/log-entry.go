@@ -25,6 +25,7 @@ import (
 
 type logEntry struct {
 	RequestID         string
+	APIKeyID          string
 	ReceivedTime      time.Time
 	RequestMethod     string
 	RequestURL        string
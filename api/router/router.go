@@ -9,25 +9,50 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog"
 
+	admission_criteria "cuide/api/resource/admission-criteria"
+	api_keys "cuide/api/resource/api-keys"
+	attendance_types "cuide/api/resource/attendance-types"
+	"cuide/api/resource/auth"
 	"cuide/api/resource/health"
 	"cuide/api/resource/places"
+	referral_ways "cuide/api/resource/referral-ways"
 	"cuide/api/resource/regionals"
 	"cuide/api/resource/segments"
 	service_types "cuide/api/resource/service-types"
+	"cuide/api/resource/synonyms"
+	"cuide/api/resource/users"
 	"cuide/api/router/middleware"
 	"cuide/api/router/middleware/requestlog"
+	"cuide/config"
+	"cuide/util/geocoder"
+	"cuide/util/jwt"
+	"cuide/util/rbac"
 )
 
-func New(l *zerolog.Logger, v *validator.Validate, db *sql.DB) *chi.Mux {
+func New(
+	c *config.Conf,
+	l *zerolog.Logger,
+	v *validator.Validate,
+	db *sql.DB,
+	g geocoder.Geocoder,
+	signer *jwt.Signer,
+	verifier *jwt.Verifier,
+) *chi.Mux {
 	r := chi.NewRouter()
 
 	r.Use(cors.Handler(cors.Options{
 		// AllowedOrigins:   []string{"https://foo.com"}, // Use this to allow specific origin hosts
 		AllowedOrigins: []string{"https://*", "http://*"},
 		// AllowOriginFunc:  func(r *http.Request, origin string) bool { return true },
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{
+			"Accept",
+			"Authorization",
+			"Content-Type",
+			"X-API-Key",
+			"X-CSRF-Token",
+		},
+		ExposedHeaders:   append([]string{"Link"}, middleware.RateLimitHeaders...),
 		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
@@ -38,47 +63,371 @@ func New(l *zerolog.Logger, v *validator.Validate, db *sql.DB) *chi.Mux {
 		r.Use(middleware.RequestID)
 		r.Use(middleware.ContentTypeJSON)
 
-		regionalAPI := regionals.New(l, v, db)
-		r.Method(http.MethodGet, "/regionals", requestlog.NewHandler(regionalAPI.List, l))
-		r.Method(http.MethodPost, "/regionals", requestlog.NewHandler(regionalAPI.Create, l))
-		r.Method(http.MethodGet, "/regionals/{id}", requestlog.NewHandler(regionalAPI.Read, l))
-		r.Method(http.MethodPut, "/regionals/{id}", requestlog.NewHandler(regionalAPI.Update, l))
-		r.Method(http.MethodDelete, "/regionals/{id}", requestlog.NewHandler(regionalAPI.Delete, l))
-
-		segmentAPI := segments.New(l, v, db)
-		r.Method(http.MethodGet, "/segments", requestlog.NewHandler(segmentAPI.List, l))
-		r.Method(http.MethodPost, "/segments", requestlog.NewHandler(segmentAPI.Create, l))
-		r.Method(http.MethodGet, "/segments/{id}", requestlog.NewHandler(segmentAPI.Read, l))
-		r.Method(http.MethodPut, "/segments/{id}", requestlog.NewHandler(segmentAPI.Update, l))
-		r.Method(http.MethodDelete, "/segments/{id}", requestlog.NewHandler(segmentAPI.Delete, l))
-
-		serviceTypeAPI := service_types.New(l, v, db)
-		r.Method(http.MethodGet, "/service-types", requestlog.NewHandler(serviceTypeAPI.List, l))
-		r.Method(http.MethodPost, "/service-types", requestlog.NewHandler(serviceTypeAPI.Create, l))
-		r.Method(
-			http.MethodGet,
-			"/service-types/{id}",
-			requestlog.NewHandler(serviceTypeAPI.Read, l),
-		)
-		r.Method(
-			http.MethodPut,
-			"/service-types/{id}",
-			requestlog.NewHandler(serviceTypeAPI.Update, l),
-		)
-		r.Method(
-			http.MethodDelete,
-			"/service-types/{id}",
-			requestlog.NewHandler(serviceTypeAPI.Delete, l),
-		)
-
-		placeAPI := places.New(l, v, db)
-		r.Method(http.MethodGet, "/places", requestlog.NewHandler(placeAPI.List, l))
-		r.Method(http.MethodPost, "/places", requestlog.NewHandler(placeAPI.Create, l))
-		r.Method(http.MethodGet, "/places/{id}", requestlog.NewHandler(placeAPI.Read, l))
-		r.Method(http.MethodPut, "/places/{id}", requestlog.NewHandler(placeAPI.Update, l))
-		r.Method(http.MethodDelete, "/places/{id}", requestlog.NewHandler(placeAPI.Delete, l))
-		r.Method(http.MethodGet, "/places/filter", requestlog.NewHandler(placeAPI.Filter, l))
+		clientIP := middleware.NewClientIP(c.RateLimit.TrustedProxies)
+		limiter := middleware.NewRateLimiter(middleware.NewMemoryStore(), l)
+
+		// Logins are limited per IP, to slow down password guessing.
+		r.Group(func(r chi.Router) {
+			r.Use(limiter.Limit("auth", rate(c.RateLimit.Auth), middleware.ByIP(clientIP)))
+
+			authAPI := auth.New(l, v, db, auth.Options{
+				Signer:          signer,
+				Issuer:          c.Auth.JWTIssuer,
+				Audience:        c.Auth.JWTAudience,
+				AccessTokenTTL:  c.Auth.AccessTokenTTL,
+				RefreshTokenTTL: c.Auth.RefreshTokenTTL,
+			})
+			r.Method(http.MethodPost, "/auth/login", requestlog.NewHandler(authAPI.Login, l))
+			r.Method(http.MethodPost, "/auth/refresh", requestlog.NewHandler(authAPI.Refresh, l))
+			r.Method(http.MethodPost, "/auth/logout", requestlog.NewHandler(authAPI.Logout, l))
+		})
+
+		authz := middleware.NewAuthorizer(users.NewRepository(db), l)
+		regionalRepository := regionals.NewRepository(db)
+
+		// Everything else needs a bearer token, or the API key of a partner
+		// system, to change data.
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.APIKeys(
+				api_keys.NewRepository(db),
+				limiter,
+				rate(c.RateLimit.Auth),
+				middleware.ByIP(clientIP),
+				l,
+			))
+			r.Use(middleware.Authenticate(verifier))
+			r.Use(limiter.Limit(
+				"default",
+				rate(c.RateLimit.Default),
+				middleware.ByIdentity(clientIP),
+			))
+
+			// Taxonomies are managed by admins.
+			r.Group(func(r chi.Router) {
+				r.Use(authz.RequireForWrites(rbac.PermTaxonomiesWrite))
+
+				regionalAPI := regionals.New(l, v, regionalRepository)
+				r.Method(http.MethodGet, "/regionals", requestlog.NewHandler(regionalAPI.List, l))
+				r.Method(
+					http.MethodPost,
+					"/regionals",
+					requestlog.NewHandler(regionalAPI.Create, l),
+				)
+				r.Method(
+					http.MethodGet,
+					"/regionals/{id}",
+					requestlog.NewHandler(regionalAPI.Read, l),
+				)
+				r.Method(
+					http.MethodPut,
+					"/regionals/{id}",
+					requestlog.NewHandler(regionalAPI.Update, l),
+				)
+				r.Method(
+					http.MethodDelete,
+					"/regionals/{id}",
+					requestlog.NewHandler(regionalAPI.Delete, l),
+				)
+				r.Method(
+					http.MethodGet,
+					"/regionals/locate",
+					requestlog.NewHandler(regionalAPI.Locate, l),
+				)
+				r.Method(
+					http.MethodGet,
+					"/regionals/{id}/boundary",
+					requestlog.NewHandler(regionalAPI.ReadBoundary, l),
+				)
+				r.Method(
+					http.MethodPut,
+					"/regionals/{id}/boundary",
+					requestlog.NewHandler(regionalAPI.UpdateBoundary, l),
+				)
+
+				segmentAPI := segments.New(l, v, db)
+				r.Method(http.MethodGet, "/segments", requestlog.NewHandler(segmentAPI.List, l))
+				r.Method(http.MethodPost, "/segments", requestlog.NewHandler(segmentAPI.Create, l))
+				r.Method(
+					http.MethodGet,
+					"/segments/{id}",
+					requestlog.NewHandler(segmentAPI.Read, l),
+				)
+				r.Method(
+					http.MethodPut,
+					"/segments/{id}",
+					requestlog.NewHandler(segmentAPI.Update, l),
+				)
+				r.Method(
+					http.MethodDelete,
+					"/segments/{id}",
+					requestlog.NewHandler(segmentAPI.Delete, l),
+				)
+
+				serviceTypeAPI := service_types.New(l, v, db)
+				r.Method(
+					http.MethodGet,
+					"/service-types",
+					requestlog.NewHandler(serviceTypeAPI.List, l),
+				)
+				r.Method(
+					http.MethodPost,
+					"/service-types",
+					requestlog.NewHandler(serviceTypeAPI.Create, l),
+				)
+				r.Method(
+					http.MethodGet,
+					"/service-types/{id}",
+					requestlog.NewHandler(serviceTypeAPI.Read, l),
+				)
+				r.Method(
+					http.MethodPut,
+					"/service-types/{id}",
+					requestlog.NewHandler(serviceTypeAPI.Update, l),
+				)
+				r.Method(
+					http.MethodDelete,
+					"/service-types/{id}",
+					requestlog.NewHandler(serviceTypeAPI.Delete, l),
+				)
+
+				admissionCriterionAPI := admission_criteria.New(l, v, db)
+				r.Method(
+					http.MethodGet,
+					"/admission-criteria",
+					requestlog.NewHandler(admissionCriterionAPI.List, l),
+				)
+				r.Method(
+					http.MethodPost,
+					"/admission-criteria",
+					requestlog.NewHandler(admissionCriterionAPI.Create, l),
+				)
+				r.Method(
+					http.MethodGet,
+					"/admission-criteria/{id}",
+					requestlog.NewHandler(admissionCriterionAPI.Read, l),
+				)
+				r.Method(
+					http.MethodPut,
+					"/admission-criteria/{id}",
+					requestlog.NewHandler(admissionCriterionAPI.Update, l),
+				)
+				r.Method(
+					http.MethodDelete,
+					"/admission-criteria/{id}",
+					requestlog.NewHandler(admissionCriterionAPI.Delete, l),
+				)
+
+				referralWayAPI := referral_ways.New(l, v, db)
+				r.Method(
+					http.MethodGet,
+					"/referral-ways",
+					requestlog.NewHandler(referralWayAPI.List, l),
+				)
+				r.Method(
+					http.MethodPost,
+					"/referral-ways",
+					requestlog.NewHandler(referralWayAPI.Create, l),
+				)
+				r.Method(
+					http.MethodGet,
+					"/referral-ways/{id}",
+					requestlog.NewHandler(referralWayAPI.Read, l),
+				)
+				r.Method(
+					http.MethodPut,
+					"/referral-ways/{id}",
+					requestlog.NewHandler(referralWayAPI.Update, l),
+				)
+				r.Method(
+					http.MethodDelete,
+					"/referral-ways/{id}",
+					requestlog.NewHandler(referralWayAPI.Delete, l),
+				)
+
+				attendanceTypeAPI := attendance_types.New(l, v, db)
+				r.Method(
+					http.MethodGet,
+					"/attendance-types",
+					requestlog.NewHandler(attendanceTypeAPI.List, l),
+				)
+				r.Method(
+					http.MethodPost,
+					"/attendance-types",
+					requestlog.NewHandler(attendanceTypeAPI.Create, l),
+				)
+				r.Method(
+					http.MethodGet,
+					"/attendance-types/{id}",
+					requestlog.NewHandler(attendanceTypeAPI.Read, l),
+				)
+				r.Method(
+					http.MethodPut,
+					"/attendance-types/{id}",
+					requestlog.NewHandler(attendanceTypeAPI.Update, l),
+				)
+				r.Method(
+					http.MethodDelete,
+					"/attendance-types/{id}",
+					requestlog.NewHandler(attendanceTypeAPI.Delete, l),
+				)
+
+				synonymAPI := synonyms.New(l, v, db)
+				r.Method(
+					http.MethodGet,
+					"/search/synonyms",
+					requestlog.NewHandler(synonymAPI.List, l),
+				)
+				r.Method(
+					http.MethodPost,
+					"/search/synonyms",
+					requestlog.NewHandler(synonymAPI.Create, l),
+				)
+				r.Method(
+					http.MethodGet,
+					"/search/synonyms/{id}",
+					requestlog.NewHandler(synonymAPI.Read, l),
+				)
+				r.Method(
+					http.MethodPut,
+					"/search/synonyms/{id}",
+					requestlog.NewHandler(synonymAPI.Update, l),
+				)
+				r.Method(
+					http.MethodDelete,
+					"/search/synonyms/{id}",
+					requestlog.NewHandler(synonymAPI.Delete, l),
+				)
+			})
+
+			hotlines := make([]*places.Hotline, len(c.Urgent.Hotlines))
+			for i, h := range c.Urgent.Hotlines {
+				hotlines[i] = &places.Hotline{Name: h.Name, Phone: h.Phone}
+			}
+
+			placeAPI := places.New(l, v, db, places.Options{
+				Geocoder:     g,
+				Regionals:    regionalRepository,
+				Hotlines:     hotlines,
+				DefaultLimit: c.Pagination.DefaultLimit,
+				MaxLimit:     c.Pagination.MaxLimit,
+			})
+
+			// Places are edited by admins and by editors, within the
+			// regionals they are assigned to. Changes the editor may not
+			// review wait for a reviewer.
+			r.Group(func(r chi.Router) {
+				r.Use(authz.RequireForWrites(rbac.PermPlacesWrite))
+
+				r.Method(http.MethodGet, "/places", requestlog.NewHandler(placeAPI.List, l))
+				r.Method(http.MethodPost, "/places", requestlog.NewHandler(placeAPI.Create, l))
+				r.Method(http.MethodGet, "/places/{id}", requestlog.NewHandler(placeAPI.Read, l))
+				r.Method(http.MethodPut, "/places/{id}", requestlog.NewHandler(placeAPI.Update, l))
+				r.Method(
+					http.MethodDelete,
+					"/places/{id}",
+					requestlog.NewHandler(placeAPI.Delete, l),
+				)
+				r.Method(
+					http.MethodGet,
+					"/places/suggest",
+					requestlog.NewHandler(placeAPI.Suggest, l),
+				)
+
+				// Filtered searches run the heavy get_servicos() join, and the
+				// urgent listing scans the opening hours of every place.
+				r.Group(func(r chi.Router) {
+					r.Use(limiter.Limit(
+						"search",
+						rate(c.RateLimit.Search),
+						middleware.ByIdentity(clientIP),
+					))
+
+					r.Method(
+						http.MethodGet,
+						"/places/filter",
+						requestlog.NewHandler(placeAPI.Filter, l),
+					)
+					r.Method(
+						http.MethodGet,
+						"/places/nearby",
+						requestlog.NewHandler(placeAPI.Nearby, l),
+					)
+					r.Method(
+						http.MethodGet,
+						"/places/urgent",
+						requestlog.NewHandler(placeAPI.Urgent, l),
+					)
+				})
+			})
+
+			// Changes to places are reviewed by admins and by reviewers,
+			// within the regionals they are assigned to.
+			r.Group(func(r chi.Router) {
+				r.Use(authz.Require(rbac.PermPlacesReview))
+
+				r.Method(
+					http.MethodGet,
+					"/places/changes",
+					requestlog.NewHandler(placeAPI.ListChanges, l),
+				)
+				r.Method(
+					http.MethodGet,
+					"/places/changes/{id}",
+					requestlog.NewHandler(placeAPI.ReadChange, l),
+				)
+				r.Method(
+					http.MethodPost,
+					"/places/changes/{id}/approve",
+					requestlog.NewHandler(placeAPI.ApproveChange, l),
+				)
+				r.Method(
+					http.MethodPost,
+					"/places/changes/{id}/reject",
+					requestlog.NewHandler(placeAPI.RejectChange, l),
+				)
+			})
+
+			// Users are managed, and read, by admins only.
+			r.Group(func(r chi.Router) {
+				r.Use(authz.Require(rbac.PermUsersManage))
+
+				userAPI := users.New(l, v, db)
+				r.Method(http.MethodGet, "/users", requestlog.NewHandler(userAPI.List, l))
+				r.Method(http.MethodPost, "/users", requestlog.NewHandler(userAPI.Create, l))
+				r.Method(http.MethodGet, "/users/{id}", requestlog.NewHandler(userAPI.Read, l))
+				r.Method(http.MethodPut, "/users/{id}", requestlog.NewHandler(userAPI.Update, l))
+				r.Method(http.MethodDelete, "/users/{id}", requestlog.NewHandler(userAPI.Delete, l))
+			})
+
+			// API keys are issued and revoked by admins.
+			r.Group(func(r chi.Router) {
+				r.Use(authz.Require(rbac.PermAPIKeysManage))
+
+				apiKeyAPI := api_keys.New(l, v, db)
+				r.Method(http.MethodGet, "/api-keys", requestlog.NewHandler(apiKeyAPI.List, l))
+				r.Method(http.MethodPost, "/api-keys", requestlog.NewHandler(apiKeyAPI.Create, l))
+				r.Method(
+					http.MethodGet,
+					"/api-keys/{id}",
+					requestlog.NewHandler(apiKeyAPI.Read, l),
+				)
+				r.Method(
+					http.MethodPut,
+					"/api-keys/{id}",
+					requestlog.NewHandler(apiKeyAPI.Update, l),
+				)
+				r.Method(
+					http.MethodDelete,
+					"/api-keys/{id}",
+					requestlog.NewHandler(apiKeyAPI.Delete, l),
+				)
+			})
+		})
 	})
 
 	return r
 }
+
+func rate(r config.Rate) middleware.Rate {
+	return middleware.Rate{Limit: r.Limit, Period: r.Period}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/rsa"
 	"database/sql"
 	"fmt"
 	"net/http"
@@ -11,7 +12,11 @@ import (
 
 	"cuide/api/router"
 	"cuide/config"
+	"cuide/migrations"
+	"cuide/util/geocoder"
+	"cuide/util/jwt"
 	"cuide/util/logger"
+	"cuide/util/migrate"
 	"cuide/util/validator"
 
 	_ "github.com/lib/pq"
@@ -25,6 +30,14 @@ const fmtDBString = "host=%s user=%s password=%s dbname=%s port=%d sslmode=requi
 
 // @host		localhost:8080
 // @basePath	/v1
+//
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+//
+// @securityDefinitions.apikey	APIKeyAuth
+// @in							header
+// @name						X-API-Key
 func main() {
 	c := config.New()
 	l := logger.New(c.Server.Debug)
@@ -44,7 +57,29 @@ func main() {
 		return
 	}
 
-	r := router.New(l, v, db)
+	m, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		l.Fatal().Err(err).Msg("Migrations loading failure")
+		return
+	}
+	if err := m.Check(context.Background()); err != nil {
+		l.Fatal().Err(err).Msg("DB schema check failure")
+		return
+	}
+
+	g, err := newGeocoder(c.Geocoder)
+	if err != nil {
+		l.Fatal().Err(err).Msg("Geocoder start failure")
+		return
+	}
+
+	signer, verifier, err := newTokens(c.Auth)
+	if err != nil {
+		l.Fatal().Err(err).Msg("Token keys loading failure")
+		return
+	}
+
+	r := router.New(c, l, v, db, g, signer, verifier)
 
 	s := &http.Server{
 		Addr:         fmt.Sprintf(":%d", c.Server.Port),
@@ -84,3 +119,60 @@ func main() {
 	<-closed
 	l.Info().Msgf("Server shutdown successfully")
 }
+
+func newGeocoder(c config.ConfGeocoder) (geocoder.Geocoder, error) {
+	switch c.Provider {
+	case "":
+		return nil, nil
+	case geocoder.SourceGazetteer:
+		return geocoder.NewGazetteer(c.GazetteerPath)
+	case geocoder.SourceNominatim:
+		if c.NominatimURL == "" {
+			return nil, fmt.Errorf("GEOCODER_NOMINATIM_URL is required by the %s geocoder", c.Provider)
+		}
+
+		client := &http.Client{Timeout: c.Timeout}
+		return geocoder.NewNominatim(client, c.NominatimURL, c.UserAgent, c.CountryCodes), nil
+	default:
+		return nil, fmt.Errorf("unknown geocoder provider %q", c.Provider)
+	}
+}
+
+func newTokens(c config.ConfAuth) (*jwt.Signer, *jwt.Verifier, error) {
+	o := jwt.Options{
+		Secret:   []byte(c.JWTSecret),
+		Issuer:   c.JWTIssuer,
+		Audience: c.JWTAudience,
+		Leeway:   c.JWTLeeway,
+	}
+
+	var privateKey *rsa.PrivateKey
+	if c.JWTPrivateKeyPath != "" {
+		key, err := jwt.ReadPrivateKey(c.JWTPrivateKeyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		privateKey = key
+		o.PublicKey = &key.PublicKey
+	}
+
+	if c.JWTPublicKeyPath != "" {
+		key, err := jwt.ReadPublicKey(c.JWTPublicKeyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		o.PublicKey = key
+	}
+
+	signer, err := jwt.NewSigner(o.Secret, privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	verifier, err := jwt.NewVerifier(o)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return signer, verifier, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+
+	"cuide/api/resource/places"
+	"cuide/config"
+	"cuide/util/logger"
+	"cuide/util/maps"
+
+	_ "github.com/lib/pq"
+)
+
+const fmtDBString = "host=%s user=%s password=%s dbname=%s port=%d sslmode=require"
+
+func main() {
+	all := flag.Bool("all", false, "re-parse every place, overwriting existing coordinates")
+	dryRun := flag.Bool("dry-run", false, "parse the links and report without writing anything")
+	flag.Parse()
+
+	c := config.NewDB()
+	l := logger.New(c.Debug)
+
+	dbString := fmt.Sprintf(
+		fmtDBString,
+		c.Host,
+		c.Username,
+		c.Password,
+		c.DBName,
+		c.Port,
+	)
+	db, err := sql.Open("postgres", dbString)
+	if err != nil {
+		l.Fatal().Err(err).Msg("DB connection start failure")
+		return
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	repository := places.NewRepository(db)
+
+	links, err := repository.MapsLinks(ctx, !*all)
+	if err != nil {
+		l.Fatal().Err(err).Msg("Places listing failure")
+		return
+	}
+
+	geocode := &places.Geocode{Source: places.GeocodeSourceMapsLink, Confidence: 1}
+
+	var updated, skipped int
+	for _, link := range links {
+		lat, lng, err := maps.ParseCoordinates(link.Link)
+		if err != nil {
+			l.Warn().Uint64("id", link.ID).Str("link", link.Link).Err(err).Msg("coordinates not found")
+			skipped++
+			continue
+		}
+
+		if !*dryRun {
+			if _, err := repository.UpdateCoordinates(ctx, link.ID, lat, lng, geocode); err != nil {
+				l.Fatal().Uint64("id", link.ID).Err(err).Msg("Place update failure")
+				return
+			}
+		}
+
+		l.Debug().Uint64("id", link.ID).Float64("latitude", lat).Float64("longitude", lng).Msg("coordinates found")
+		updated++
+	}
+
+	l.Info().
+		Int("places", len(links)).
+		Int("updated", updated).
+		Int("skipped", skipped).
+		Bool("dry_run", *dryRun).
+		Msg("backfill completed")
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"cuide/api/resource/users"
+	"cuide/config"
+	"cuide/util/logger"
+	"cuide/util/rbac"
+	"cuide/util/validator"
+
+	_ "github.com/lib/pq"
+)
+
+const fmtDBString = "host=%s user=%s password=%s dbname=%s port=%d sslmode=require"
+
+const passwordEnv = "BOOTSTRAP_ADMIN_PASSWORD"
+
+// bootstrap-admin creates the first admin user, who can then create the
+// others through /v1/users. It refuses to run once an active admin exists.
+func main() {
+	email := flag.String("email", "", "email of the admin")
+	name := flag.String("name", "Admin", "name of the admin")
+	flag.Usage = func() {
+		fmt.Fprintf(
+			flag.CommandLine.Output(),
+			"usage: bootstrap-admin -email address [-name name]\n\n"+
+				"The password is read from %s or, when unset, from the first line of stdin.\n\n",
+			passwordEnv,
+		)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	c := config.NewDB()
+	l := logger.New(c.Debug)
+
+	password, ok := os.LookupEnv(passwordEnv)
+	if !ok {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			l.Fatal().Err(err).Msg("Password reading failure")
+			return
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	form := &users.Form{
+		Name:     *name,
+		Email:    *email,
+		Password: password,
+		Roles:    []users.RoleAssignment{{Role: rbac.RoleAdmin}},
+	}
+	if err := validator.New().Struct(form); err != nil {
+		l.Fatal().Strs("errors", validator.ToErrResponse(err).Errors).Msg("Invalid admin")
+		return
+	}
+
+	dbString := fmt.Sprintf(
+		fmtDBString,
+		c.Host,
+		c.Username,
+		c.Password,
+		c.DBName,
+		c.Port,
+	)
+	db, err := sql.Open("postgres", dbString)
+	if err != nil {
+		l.Fatal().Err(err).Msg("DB connection start failure")
+		return
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	repository := users.NewRepository(db)
+
+	exists, err := repository.HasAdmin(ctx)
+	if err != nil {
+		l.Fatal().Err(err).Msg("Admin lookup failure")
+		return
+	}
+	if exists {
+		l.Fatal().Msg("An admin already exists, create other users through /v1/users")
+		return
+	}
+
+	admin, err := form.ToModel()
+	if err != nil {
+		l.Fatal().Err(err).Msg("Password hash failure")
+		return
+	}
+
+	if _, err := repository.Create(&admin); err != nil {
+		l.Fatal().Err(err).Msg("Admin creation failure")
+		return
+	}
+
+	l.Info().Uint64("id", admin.ID).Str("email", admin.Email).Msg("admin created")
+}
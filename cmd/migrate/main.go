@@ -1 +1,158 @@
-package main
\ No newline at end of file
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"cuide/config"
+	"cuide/migrations"
+	"cuide/util/logger"
+	"cuide/util/migrate"
+
+	_ "github.com/lib/pq"
+)
+
+const fmtDBString = "host=%s user=%s password=%s dbname=%s port=%d sslmode=require"
+
+const usage = `usage: migrate [-dir path] <command> [arg]
+
+commands:
+  up [N]     apply all pending migrations, or only the next N
+  down [N]   revert the last N applied migrations (default 1)
+  status     list migrations and whether they are applied
+  goto V     migrate up or down to version V (0 reverts everything)
+  force V    record version V as applied and clean without running any file
+`
+
+func main() {
+	dir := flag.String(
+		"dir",
+		"",
+		"directory containing the *.up.sql and *.down.sql files (defaults to the embedded migrations)",
+	)
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	c := config.NewDB()
+	l := logger.New(c.Debug)
+
+	dbString := fmt.Sprintf(
+		fmtDBString,
+		c.Host,
+		c.Username,
+		c.Password,
+		c.DBName,
+		c.Port,
+	)
+	db, err := sql.Open("postgres", dbString)
+	if err != nil {
+		l.Fatal().Err(err).Msg("DB connection start failure")
+		return
+	}
+	defer db.Close()
+
+	var fsys fs.FS = migrations.FS
+	if *dir != "" {
+		fsys = os.DirFS(*dir)
+	}
+
+	m, err := migrate.New(db, fsys)
+	if err != nil {
+		l.Fatal().Err(err).Msg("Migrations loading failure")
+		return
+	}
+
+	ctx := context.Background()
+	cmd, arg := flag.Arg(0), flag.Arg(1)
+
+	var versions []uint64
+	switch cmd {
+	case "up":
+		versions, err = runCount(arg, 0, func(n int) ([]uint64, error) { return m.Up(ctx, n) })
+	case "down":
+		versions, err = runCount(arg, 1, func(n int) ([]uint64, error) { return m.Down(ctx, n) })
+	case "goto":
+		var v uint64
+		if v, err = strconv.ParseUint(arg, 10, 64); err == nil {
+			versions, err = m.Goto(ctx, v)
+		}
+	case "force":
+		var v uint64
+		if v, err = strconv.ParseUint(arg, 10, 64); err == nil {
+			if err = m.Force(ctx, v); err == nil {
+				l.Info().Uint64("version", v).Msg("version forced")
+			}
+		}
+	case "status":
+		err = printStatus(ctx, m)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	for _, v := range versions {
+		l.Info().Str("command", cmd).Uint64("version", v).Msg("migration completed")
+	}
+
+	if errors.Is(err, migrate.ErrNoChange) {
+		l.Info().Str("command", cmd).Msg("no change")
+		return
+	}
+	if err != nil {
+		l.Fatal().Str("command", cmd).Err(err).Msg("Migration failure")
+	}
+}
+
+func runCount(arg string, def int, fn func(n int) ([]uint64, error)) ([]uint64, error) {
+	if arg == "" {
+		return fn(def)
+	}
+
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("N must be greater than 0, got %d", n)
+	}
+
+	return fn(n)
+}
+
+func printStatus(ctx context.Context, m *migrate.Migrator) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		state, appliedAt := "pending", ""
+		switch {
+		case s.Dirty:
+			state, appliedAt = "dirty", s.AppliedAt.Format("2006-01-02 15:04:05")
+		case s.Applied:
+			state, appliedAt = "applied", s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", s.Migration.Version, s.Migration.Name, state, appliedAt)
+	}
+
+	return tw.Flush()
+}
@@ -1,15 +1,24 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"net/netip"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joeshaw/envdecode"
 )
 
 type Conf struct {
-	Server ConfServer
-	DB     ConfDB
+	Server     ConfServer
+	DB         ConfDB
+	Geocoder   ConfGeocoder
+	Urgent     ConfUrgent
+	Pagination ConfPagination
+	Auth       ConfAuth
+	RateLimit  ConfRateLimit
 }
 
 type ConfServer struct {
@@ -29,11 +38,167 @@ type ConfDB struct {
 	Debug    bool   `env:"DB_DEBUG,required"`
 }
 
+// ConfGeocoder selects how addresses are geocoded when a place has no
+// coordinates. Provider is empty to disable geocoding, "gazetteer" for the
+// local CSV at GazetteerPath or "nominatim" for the server at NominatimURL.
+type ConfGeocoder struct {
+	Provider      string        `env:"GEOCODER_PROVIDER"`
+	GazetteerPath string        `env:"GEOCODER_GAZETTEER_PATH"`
+	NominatimURL  string        `env:"GEOCODER_NOMINATIM_URL"`
+	UserAgent     string        `env:"GEOCODER_USER_AGENT,default=cuide-api"`
+	CountryCodes  string        `env:"GEOCODER_COUNTRY_CODES,default=br"`
+	Timeout       time.Duration `env:"GEOCODER_TIMEOUT,default=5s"`
+}
+
+// ConfAuth configures the bearer tokens required by the routes that change
+// data. Tokens are signed with RS256 when JWTPrivateKeyPath is set and with
+// HS256 and JWTSecret otherwise. They are verified with JWTSecret for HS256
+// and with the PEM public key at JWTPublicKeyPath for RS256, which defaults
+// to the public half of JWTPrivateKeyPath. JWTSecret must be at least 32
+// random bytes.
+type ConfAuth struct {
+	JWTSecret         string        `env:"AUTH_JWT_SECRET"`
+	JWTPrivateKeyPath string        `env:"AUTH_JWT_PRIVATE_KEY_PATH"`
+	JWTPublicKeyPath  string        `env:"AUTH_JWT_PUBLIC_KEY_PATH"`
+	JWTIssuer         string        `env:"AUTH_JWT_ISSUER,required"`
+	JWTAudience       string        `env:"AUTH_JWT_AUDIENCE"`
+	JWTLeeway         time.Duration `env:"AUTH_JWT_LEEWAY,default=30s"`
+	AccessTokenTTL    time.Duration `env:"AUTH_ACCESS_TOKEN_TTL,default=15m"`
+	RefreshTokenTTL   time.Duration `env:"AUTH_REFRESH_TOKEN_TTL,default=720h"`
+}
+
+// ConfRateLimit sets the rates, as "requests/period", allowed to each client
+// IP or authenticated user. Search is also applied to the heavy place
+// searches and Auth, per IP, to the login and token routes and to unknown API
+// keys. X-Forwarded-For
+// is only believed from TrustedProxies, a "," separated list of IPs and
+// CIDR prefixes.
+type ConfRateLimit struct {
+	TrustedProxies Prefixes `env:"RATE_LIMIT_TRUSTED_PROXIES"`
+	Default        Rate     `env:"RATE_LIMIT_DEFAULT,default=300/1m"`
+	Search         Rate     `env:"RATE_LIMIT_SEARCH,default=30/1m"`
+	Auth           Rate     `env:"RATE_LIMIT_AUTH,default=10/1m"`
+}
+
+type Rate struct {
+	Limit  int
+	Period time.Duration
+}
+
+func (r *Rate) Decode(env string) error {
+	limit, period, ok := strings.Cut(env, "/")
+	if !ok {
+		return fmt.Errorf("invalid rate %q, want requests/period", env)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(limit))
+	if err != nil || n <= 0 {
+		return fmt.Errorf("invalid rate %q, want a positive number of requests", env)
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		return fmt.Errorf("invalid rate %q, want a positive period", env)
+	}
+
+	*r = Rate{Limit: n, Period: d}
+	return nil
+}
+
+type Prefixes []netip.Prefix
+
+// Decode accepts IPs, which are read as prefixes of a single address, as
+// well as CIDR prefixes.
+func (p *Prefixes) Decode(env string) error {
+	*p = nil
+	for _, v := range strings.Split(env, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		if addr, err := netip.ParseAddr(v); err == nil {
+			*p = append(*p, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q, want an IP or a CIDR prefix", v)
+		}
+		*p = append(*p, prefix.Masked())
+	}
+
+	return nil
+}
+
+// ConfPagination bounds the limit param of paginated listings.
+type ConfPagination struct {
+	DefaultLimit uint64 `env:"PAGINATION_DEFAULT_LIMIT,default=20"`
+	MaxLimit     uint64 `env:"PAGINATION_MAX_LIMIT,default=100"`
+}
+
+// Validate requires positive limits, the default one within the maximum.
+func (c ConfPagination) Validate() error {
+	if c.DefaultLimit < 1 {
+		return fmt.Errorf("PAGINATION_DEFAULT_LIMIT must be at least 1, got %d", c.DefaultLimit)
+	}
+	if c.MaxLimit < 1 {
+		return fmt.Errorf("PAGINATION_MAX_LIMIT must be at least 1, got %d", c.MaxLimit)
+	}
+	if c.DefaultLimit > c.MaxLimit {
+		return fmt.Errorf(
+			"PAGINATION_DEFAULT_LIMIT (%d) must not exceed PAGINATION_MAX_LIMIT (%d)",
+			c.DefaultLimit,
+			c.MaxLimit,
+		)
+	}
+
+	return nil
+}
+
+// ConfUrgent holds the hotlines listed by /places/urgent when no place is
+// open, as a ";" separated list of "name:phone".
+type ConfUrgent struct {
+	Hotlines Hotlines `env:"URGENT_HOTLINES,default=CVV:188;SAMU:192"`
+}
+
+type Hotline struct {
+	Name  string
+	Phone string
+}
+
+type Hotlines []Hotline
+
+func (h *Hotlines) Decode(env string) error {
+	*h = nil
+	for _, v := range strings.Split(env, ";") {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+
+		name, phone, ok := strings.Cut(v, ":")
+		if !ok || strings.TrimSpace(name) == "" || strings.TrimSpace(phone) == "" {
+			return fmt.Errorf("invalid hotline %q, want name:phone", v)
+		}
+
+		*h = append(*h, Hotline{
+			Name:  strings.TrimSpace(name),
+			Phone: strings.TrimSpace(phone),
+		})
+	}
+
+	return nil
+}
+
 func New() *Conf {
 	var c Conf
 	if err := envdecode.StrictDecode(&c); err != nil {
 		log.Fatalf("Failed to decode: %s", err)
 	}
+	if err := c.Pagination.Validate(); err != nil {
+		log.Fatalf("Failed to decode: %s", err)
+	}
 
 	return &c
 }
@@ -45,4 +210,4 @@ func NewDB() *ConfDB {
 	}
 
 	return &c
-}
\ No newline at end of file
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestConfPaginationValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       ConfPagination
+		wantErr bool
+	}{
+		{"defaults", ConfPagination{DefaultLimit: 20, MaxLimit: 100}, false},
+		{"equal limits", ConfPagination{DefaultLimit: 50, MaxLimit: 50}, false},
+		{"zero default", ConfPagination{DefaultLimit: 0, MaxLimit: 100}, true},
+		{"zero max", ConfPagination{DefaultLimit: 20, MaxLimit: 0}, true},
+		{"default over max", ConfPagination{DefaultLimit: 200, MaxLimit: 100}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRateDecode(t *testing.T) {
+	tests := []struct {
+		env     string
+		want    Rate
+		wantErr bool
+	}{
+		{"300/1m", Rate{Limit: 300, Period: time.Minute}, false},
+		{" 10 / 30s ", Rate{Limit: 10, Period: 30 * time.Second}, false},
+		{"300", Rate{}, true},
+		{"0/1m", Rate{}, true},
+		{"-1/1m", Rate{}, true},
+		{"ten/1m", Rate{}, true},
+		{"10/0s", Rate{}, true},
+		{"10/minute", Rate{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			var r Rate
+			err := r.Decode(tt.env)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode = %v, want error %v", err, tt.wantErr)
+			}
+			if r != tt.want {
+				t.Errorf("Rate = %+v, want %+v", r, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrefixesDecode(t *testing.T) {
+	tests := []struct {
+		env     string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"10.0.0.1", []string{"10.0.0.1/32"}, false},
+		{"10.1.2.3/8, 2001:db8::/32", []string{"10.0.0.0/8", "2001:db8::/32"}, false},
+		{"::ffff:10.0.0.1,", []string{"10.0.0.1/32"}, false},
+		{"10.0.0.1,proxy", nil, true},
+		{"10.0.0.0/33", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			var p Prefixes
+			err := p.Decode(tt.env)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var want []netip.Prefix
+			for _, s := range tt.want {
+				want = append(want, netip.MustParsePrefix(s))
+			}
+			if !slices.Equal(p, want) {
+				t.Errorf("Prefixes = %v, want %v", p, want)
+			}
+		})
+	}
+}
+
+func TestHotlinesDecode(t *testing.T) {
+	tests := []struct {
+		env     string
+		want    Hotlines
+		wantErr bool
+	}{
+		{"CVV:188;SAMU:192", Hotlines{{Name: "CVV", Phone: "188"}, {Name: "SAMU", Phone: "192"}}, false},
+		{" CVV : 188 ; ", Hotlines{{Name: "CVV", Phone: "188"}}, false},
+		{"", nil, false},
+		{"CVV", nil, true},
+		{"CVV:", nil, true},
+		{":188", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			var h Hotlines
+			err := h.Decode(tt.env)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(h, tt.want) {
+				t.Errorf("Hotlines = %v, want %v", h, tt.want)
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@ require (
 	github.com/lib/pq v1.10.9
 	github.com/rs/xid v1.5.0
 	github.com/rs/zerolog v1.33.0
+	golang.org/x/crypto v0.27.0
 )
 
 require (
@@ -19,7 +20,6 @@ require (
 	github.com/leodido/go-urn v1.4.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.19 // indirect
-	golang.org/x/crypto v0.27.0 // indirect
 	golang.org/x/net v0.21.0 // indirect
 	golang.org/x/sys v0.25.0 // indirect
 	golang.org/x/text v0.18.0 // indirect
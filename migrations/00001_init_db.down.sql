@@ -1,7 +1,3 @@
-BEGIN;
-
-DROP TABLE public.servico;
-
 DROP TABLE public.criterios_admissao_servico;
 
 DROP TABLE public.criterios_admissao;
@@ -14,6 +10,8 @@ DROP TABLE public.tipo_atendimento_servico;
 
 DROP TABLE public.tipo_atendimento;
 
+DROP TABLE public.servico;
+
 DROP TABLE public.tipo_servico;
 
-COMMIT;
\ No newline at end of file
+DROP TABLE public.regionais;
@@ -1,5 +1,3 @@
-BEGIN;
-
 CREATE TABLE criterios_admissao (
   id integer NOT NULL GENERATED ALWAYS AS IDENTITY UNIQUE,
   nome varchar NOT NULL,
@@ -97,5 +95,3 @@ ALTER TABLE
   forma_encaminhamento_servico
 ADD
   CONSTRAINT FK_servico_TO_forma_encaminhamento_servico FOREIGN KEY (servico_id) REFERENCES servico (id);
-
-COMMIT;
\ No newline at end of file
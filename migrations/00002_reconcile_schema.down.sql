@@ -0,0 +1,32 @@
+DROP FUNCTION IF EXISTS get_servicos();
+
+ALTER TABLE
+  servico
+ADD
+  COLUMN regional_id integer;
+
+-- The original NOT NULL constraint cannot be restored for places without a
+-- regional, so only the lowest regional of each place is carried back.
+UPDATE
+  servico s
+SET
+  regional_id = (
+    SELECT min(rs.regional_id) FROM regionais_servico rs WHERE rs.servico_id = s.id
+  );
+
+ALTER TABLE
+  servico
+ADD
+  CONSTRAINT FK_regionais_TO_servico FOREIGN KEY (regional_id) REFERENCES regionais (id);
+
+DROP TABLE regionais_servico;
+
+ALTER TABLE
+  servico DROP COLUMN eixo_id,
+  DROP COLUMN maps_link,
+  DROP COLUMN google_maps_embed_link,
+  DROP COLUMN criterios_admissao,
+  DROP COLUMN tipo_atendimento,
+  DROP COLUMN forma_encaminhamento;
+
+DROP TABLE eixo;
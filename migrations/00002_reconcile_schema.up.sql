@@ -0,0 +1,113 @@
+CREATE TABLE IF NOT EXISTS eixo (
+  id integer NOT NULL GENERATED ALWAYS AS IDENTITY UNIQUE,
+  nome varchar NOT NULL,
+  PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS regionais_servico (
+  servico_id integer NOT NULL,
+  regional_id integer NOT NULL,
+  PRIMARY KEY (servico_id, regional_id)
+);
+
+ALTER TABLE
+  servico
+ADD
+  COLUMN IF NOT EXISTS eixo_id integer,
+ADD
+  COLUMN IF NOT EXISTS maps_link varchar,
+ADD
+  COLUMN IF NOT EXISTS google_maps_embed_link varchar,
+ADD
+  COLUMN IF NOT EXISTS criterios_admissao varchar,
+ADD
+  COLUMN IF NOT EXISTS tipo_atendimento varchar,
+ADD
+  COLUMN IF NOT EXISTS forma_encaminhamento varchar;
+
+-- servico.regional_id is replaced by regionais_servico, which the places
+-- repository writes to. The column was NOT NULL, so no place could be created.
+DO $$
+BEGIN
+  IF EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_schema = 'public' AND table_name = 'servico' AND column_name = 'regional_id'
+  ) THEN
+    INSERT INTO regionais_servico (servico_id, regional_id)
+    SELECT id, regional_id FROM servico WHERE regional_id IS NOT NULL
+    ON CONFLICT DO NOTHING;
+
+    ALTER TABLE servico DROP COLUMN regional_id;
+  END IF;
+END $$;
+
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_eixo_to_servico') THEN
+    ALTER TABLE servico
+    ADD CONSTRAINT FK_eixo_TO_servico FOREIGN KEY (eixo_id) REFERENCES eixo (id);
+  END IF;
+
+  IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_servico_to_regionais_servico') THEN
+    ALTER TABLE regionais_servico
+    ADD CONSTRAINT FK_servico_TO_regionais_servico FOREIGN KEY (servico_id) REFERENCES servico (id);
+  END IF;
+
+  IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_regionais_to_regionais_servico') THEN
+    ALTER TABLE regionais_servico
+    ADD CONSTRAINT FK_regionais_TO_regionais_servico FOREIGN KEY (regional_id) REFERENCES regionais (id);
+  END IF;
+END $$;
+
+DROP FUNCTION IF EXISTS get_servicos();
+
+-- get_servicos returns one row per place, in the column order scanned by
+-- places.Repository.
+CREATE FUNCTION get_servicos()
+RETURNS TABLE (
+  servico_id integer,
+  servico_nome text,
+  servico_endereco text,
+  servico_contato text,
+  servico_site text,
+  servico_observacoes text,
+  servico_maps_link text,
+  servico_maps_embed_link text,
+  servico_criterios_admissao text,
+  servico_tipo_atendimento text,
+  servico_forma_encaminhamento text,
+  tipo_servico jsonb,
+  eixo jsonb,
+  regionais jsonb
+)
+LANGUAGE sql STABLE AS $$
+  SELECT
+    s.id,
+    s.nome::text,
+    s.endereco::text,
+    coalesce(s.contato, '')::text,
+    coalesce(s.site, '')::text,
+    coalesce(s.observacoes, '')::text,
+    coalesce(s.maps_link, '')::text,
+    coalesce(s.google_maps_embed_link, '')::text,
+    coalesce(s.criterios_admissao, '')::text,
+    coalesce(s.tipo_atendimento, '')::text,
+    coalesce(s.forma_encaminhamento, '')::text,
+    jsonb_build_object('id', ts.id, 'name', ts.nome),
+    jsonb_build_object('id', e.id, 'name', e.nome),
+    coalesce(
+      jsonb_agg(DISTINCT jsonb_build_object('id', r.id, 'name', r.nome))
+        FILTER (WHERE r.id IS NOT NULL),
+      '[]'::jsonb
+    )
+  FROM
+    public.servico s
+    LEFT JOIN public.tipo_servico ts ON s.tipo_servico_id = ts.id
+    LEFT JOIN public.eixo e ON s.eixo_id = e.id
+    LEFT JOIN public.regionais_servico rs ON s.id = rs.servico_id
+    LEFT JOIN public.regionais r ON rs.regional_id = r.id
+  GROUP BY
+    s.id, ts.id, ts.nome, e.id, e.nome
+  ORDER BY
+    s.id
+$$;
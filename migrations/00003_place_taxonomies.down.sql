@@ -0,0 +1,83 @@
+DROP FUNCTION get_servicos();
+
+ALTER TABLE
+  servico
+ADD
+  COLUMN criterios_admissao varchar,
+ADD
+  COLUMN tipo_atendimento varchar,
+ADD
+  COLUMN forma_encaminhamento varchar;
+
+-- A place may now be linked to several taxonomy entries, so they are joined
+-- back into a single free-text value.
+UPDATE
+  servico s
+SET
+  criterios_admissao = (
+    SELECT string_agg(ca.nome, ', ' ORDER BY ca.id)
+    FROM criterios_admissao_servico cas
+    JOIN criterios_admissao ca ON cas.criterio_admissao_id = ca.id
+    WHERE cas.servico_id = s.id
+  ),
+  tipo_atendimento = (
+    SELECT string_agg(ta.nome, ', ' ORDER BY ta.id)
+    FROM tipo_atendimento_servico tas
+    JOIN tipo_atendimento ta ON tas.tipo_atendimento_id = ta.id
+    WHERE tas.servico_id = s.id
+  ),
+  forma_encaminhamento = (
+    SELECT string_agg(fe.nome, ', ' ORDER BY fe.id)
+    FROM forma_encaminhamento_servico fes
+    JOIN forma_encaminhamento fe ON fes.forma_encaminhamento_id = fe.id
+    WHERE fes.servico_id = s.id
+  );
+
+CREATE FUNCTION get_servicos()
+RETURNS TABLE (
+  servico_id integer,
+  servico_nome text,
+  servico_endereco text,
+  servico_contato text,
+  servico_site text,
+  servico_observacoes text,
+  servico_maps_link text,
+  servico_maps_embed_link text,
+  servico_criterios_admissao text,
+  servico_tipo_atendimento text,
+  servico_forma_encaminhamento text,
+  tipo_servico jsonb,
+  eixo jsonb,
+  regionais jsonb
+)
+LANGUAGE sql STABLE AS $$
+  SELECT
+    s.id,
+    s.nome::text,
+    s.endereco::text,
+    coalesce(s.contato, '')::text,
+    coalesce(s.site, '')::text,
+    coalesce(s.observacoes, '')::text,
+    coalesce(s.maps_link, '')::text,
+    coalesce(s.google_maps_embed_link, '')::text,
+    coalesce(s.criterios_admissao, '')::text,
+    coalesce(s.tipo_atendimento, '')::text,
+    coalesce(s.forma_encaminhamento, '')::text,
+    jsonb_build_object('id', ts.id, 'name', ts.nome),
+    jsonb_build_object('id', e.id, 'name', e.nome),
+    coalesce(
+      jsonb_agg(DISTINCT jsonb_build_object('id', r.id, 'name', r.nome))
+        FILTER (WHERE r.id IS NOT NULL),
+      '[]'::jsonb
+    )
+  FROM
+    public.servico s
+    LEFT JOIN public.tipo_servico ts ON s.tipo_servico_id = ts.id
+    LEFT JOIN public.eixo e ON s.eixo_id = e.id
+    LEFT JOIN public.regionais_servico rs ON s.id = rs.servico_id
+    LEFT JOIN public.regionais r ON rs.regional_id = r.id
+  GROUP BY
+    s.id, ts.id, ts.nome, e.id, e.nome
+  ORDER BY
+    s.id
+$$;
@@ -0,0 +1,133 @@
+-- The free-text columns added in 00002 become links to the criterios_admissao,
+-- forma_encaminhamento and tipo_atendimento taxonomies.
+INSERT INTO
+  criterios_admissao (nome)
+SELECT
+  DISTINCT s.criterios_admissao
+FROM
+  servico s
+WHERE
+  coalesce(s.criterios_admissao, '') <> ''
+  AND NOT EXISTS (
+    SELECT 1 FROM criterios_admissao ca WHERE ca.nome = s.criterios_admissao
+  );
+
+INSERT INTO
+  forma_encaminhamento (nome)
+SELECT
+  DISTINCT s.forma_encaminhamento
+FROM
+  servico s
+WHERE
+  coalesce(s.forma_encaminhamento, '') <> ''
+ON CONFLICT (nome) DO NOTHING;
+
+INSERT INTO
+  tipo_atendimento (nome)
+SELECT
+  DISTINCT s.tipo_atendimento
+FROM
+  servico s
+WHERE
+  coalesce(s.tipo_atendimento, '') <> ''
+ON CONFLICT (nome) DO NOTHING;
+
+INSERT INTO
+  criterios_admissao_servico (servico_id, criterio_admissao_id)
+SELECT
+  s.id,
+  min(ca.id)
+FROM
+  servico s
+  JOIN criterios_admissao ca ON ca.nome = s.criterios_admissao
+GROUP BY
+  s.id;
+
+INSERT INTO
+  forma_encaminhamento_servico (servico_id, forma_encaminhamento_id)
+SELECT
+  s.id,
+  fe.id
+FROM
+  servico s
+  JOIN forma_encaminhamento fe ON fe.nome = s.forma_encaminhamento;
+
+INSERT INTO
+  tipo_atendimento_servico (servico_id, tipo_atendimento_id)
+SELECT
+  s.id,
+  ta.id
+FROM
+  servico s
+  JOIN tipo_atendimento ta ON ta.nome = s.tipo_atendimento;
+
+DROP FUNCTION get_servicos();
+
+ALTER TABLE
+  servico DROP COLUMN criterios_admissao,
+  DROP COLUMN tipo_atendimento,
+  DROP COLUMN forma_encaminhamento;
+
+-- get_servicos returns one row per place, in the column order scanned by
+-- places.Repository. Every relation is aggregated in its own subquery so the
+-- joins do not multiply each other.
+CREATE FUNCTION get_servicos()
+RETURNS TABLE (
+  servico_id integer,
+  servico_nome text,
+  servico_endereco text,
+  servico_contato text,
+  servico_site text,
+  servico_observacoes text,
+  servico_maps_link text,
+  servico_maps_embed_link text,
+  criterios_admissao jsonb,
+  tipos_atendimento jsonb,
+  formas_encaminhamento jsonb,
+  tipo_servico jsonb,
+  eixo jsonb,
+  regionais jsonb
+)
+LANGUAGE sql STABLE AS $$
+  SELECT
+    s.id,
+    s.nome::text,
+    s.endereco::text,
+    coalesce(s.contato, '')::text,
+    coalesce(s.site, '')::text,
+    coalesce(s.observacoes, '')::text,
+    coalesce(s.maps_link, '')::text,
+    coalesce(s.google_maps_embed_link, '')::text,
+    coalesce((
+      SELECT jsonb_agg(jsonb_build_object('id', ca.id, 'name', ca.nome) ORDER BY ca.id)
+      FROM public.criterios_admissao_servico cas
+      JOIN public.criterios_admissao ca ON cas.criterio_admissao_id = ca.id
+      WHERE cas.servico_id = s.id
+    ), '[]'::jsonb),
+    coalesce((
+      SELECT jsonb_agg(jsonb_build_object('id', ta.id, 'name', ta.nome) ORDER BY ta.id)
+      FROM public.tipo_atendimento_servico tas
+      JOIN public.tipo_atendimento ta ON tas.tipo_atendimento_id = ta.id
+      WHERE tas.servico_id = s.id
+    ), '[]'::jsonb),
+    coalesce((
+      SELECT jsonb_agg(jsonb_build_object('id', fe.id, 'name', fe.nome) ORDER BY fe.id)
+      FROM public.forma_encaminhamento_servico fes
+      JOIN public.forma_encaminhamento fe ON fes.forma_encaminhamento_id = fe.id
+      WHERE fes.servico_id = s.id
+    ), '[]'::jsonb),
+    jsonb_build_object('id', ts.id, 'name', ts.nome),
+    jsonb_build_object('id', e.id, 'name', e.nome),
+    coalesce((
+      SELECT jsonb_agg(jsonb_build_object('id', r.id, 'name', r.nome) ORDER BY r.id)
+      FROM public.regionais_servico rs
+      JOIN public.regionais r ON rs.regional_id = r.id
+      WHERE rs.servico_id = s.id
+    ), '[]'::jsonb)
+  FROM
+    public.servico s
+    LEFT JOIN public.tipo_servico ts ON s.tipo_servico_id = ts.id
+    LEFT JOIN public.eixo e ON s.eixo_id = e.id
+  ORDER BY
+    s.id
+$$;
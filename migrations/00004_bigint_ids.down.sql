@@ -0,0 +1,145 @@
+DROP FUNCTION get_servicos();
+
+ALTER TABLE
+  criterios_admissao
+ALTER
+  COLUMN id TYPE integer;
+
+ALTER TABLE
+  forma_encaminhamento
+ALTER
+  COLUMN id TYPE integer;
+
+ALTER TABLE
+  regionais
+ALTER
+  COLUMN id TYPE integer;
+
+ALTER TABLE
+  servico
+ALTER
+  COLUMN id TYPE integer,
+ALTER
+  COLUMN tipo_servico_id TYPE integer,
+ALTER
+  COLUMN eixo_id TYPE integer;
+
+ALTER TABLE
+  tipo_atendimento
+ALTER
+  COLUMN id TYPE integer;
+
+ALTER TABLE
+  tipo_servico
+ALTER
+  COLUMN id TYPE integer;
+
+ALTER TABLE
+  eixo
+ALTER
+  COLUMN id TYPE integer;
+
+ALTER TABLE
+  criterios_admissao_servico
+ALTER
+  COLUMN servico_id TYPE integer,
+ALTER
+  COLUMN criterio_admissao_id TYPE integer;
+
+ALTER TABLE
+  forma_encaminhamento_servico
+ALTER
+  COLUMN servico_id TYPE integer,
+ALTER
+  COLUMN forma_encaminhamento_id TYPE integer;
+
+ALTER TABLE
+  tipo_atendimento_servico
+ALTER
+  COLUMN servico_id TYPE integer,
+ALTER
+  COLUMN tipo_atendimento_id TYPE integer;
+
+ALTER TABLE
+  regionais_servico
+ALTER
+  COLUMN servico_id TYPE integer,
+ALTER
+  COLUMN regional_id TYPE integer;
+
+-- Keep the identity sequences in line with their columns.
+DO $$
+DECLARE
+  t text;
+BEGIN
+  FOREACH t IN ARRAY ARRAY[
+    'criterios_admissao', 'forma_encaminhamento', 'regionais', 'servico',
+    'tipo_atendimento', 'tipo_servico', 'eixo'
+  ] LOOP
+    EXECUTE format('ALTER SEQUENCE %s AS integer', pg_get_serial_sequence(t, 'id'));
+  END LOOP;
+END $$;
+
+-- get_servicos returns one row per place, in the column order scanned by
+-- places.Repository. Every relation is aggregated in its own subquery so the
+-- joins do not multiply each other.
+CREATE FUNCTION get_servicos()
+RETURNS TABLE (
+  servico_id integer,
+  servico_nome text,
+  servico_endereco text,
+  servico_contato text,
+  servico_site text,
+  servico_observacoes text,
+  servico_maps_link text,
+  servico_maps_embed_link text,
+  criterios_admissao jsonb,
+  tipos_atendimento jsonb,
+  formas_encaminhamento jsonb,
+  tipo_servico jsonb,
+  eixo jsonb,
+  regionais jsonb
+)
+LANGUAGE sql STABLE AS $$
+  SELECT
+    s.id,
+    s.nome::text,
+    s.endereco::text,
+    coalesce(s.contato, '')::text,
+    coalesce(s.site, '')::text,
+    coalesce(s.observacoes, '')::text,
+    coalesce(s.maps_link, '')::text,
+    coalesce(s.google_maps_embed_link, '')::text,
+    coalesce((
+      SELECT jsonb_agg(jsonb_build_object('id', ca.id, 'name', ca.nome) ORDER BY ca.id)
+      FROM public.criterios_admissao_servico cas
+      JOIN public.criterios_admissao ca ON cas.criterio_admissao_id = ca.id
+      WHERE cas.servico_id = s.id
+    ), '[]'::jsonb),
+    coalesce((
+      SELECT jsonb_agg(jsonb_build_object('id', ta.id, 'name', ta.nome) ORDER BY ta.id)
+      FROM public.tipo_atendimento_servico tas
+      JOIN public.tipo_atendimento ta ON tas.tipo_atendimento_id = ta.id
+      WHERE tas.servico_id = s.id
+    ), '[]'::jsonb),
+    coalesce((
+      SELECT jsonb_agg(jsonb_build_object('id', fe.id, 'name', fe.nome) ORDER BY fe.id)
+      FROM public.forma_encaminhamento_servico fes
+      JOIN public.forma_encaminhamento fe ON fes.forma_encaminhamento_id = fe.id
+      WHERE fes.servico_id = s.id
+    ), '[]'::jsonb),
+    jsonb_build_object('id', ts.id, 'name', ts.nome),
+    jsonb_build_object('id', e.id, 'name', e.nome),
+    coalesce((
+      SELECT jsonb_agg(jsonb_build_object('id', r.id, 'name', r.nome) ORDER BY r.id)
+      FROM public.regionais_servico rs
+      JOIN public.regionais r ON rs.regional_id = r.id
+      WHERE rs.servico_id = s.id
+    ), '[]'::jsonb)
+  FROM
+    public.servico s
+    LEFT JOIN public.tipo_servico ts ON s.tipo_servico_id = ts.id
+    LEFT JOIN public.eixo e ON s.eixo_id = e.id
+  ORDER BY
+    s.id
+$$;
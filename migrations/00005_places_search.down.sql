@@ -0,0 +1,32 @@
+DROP TRIGGER tipo_atendimento_search ON tipo_atendimento;
+
+DROP TRIGGER criterios_admissao_search ON criterios_admissao;
+
+DROP TRIGGER regionais_search ON regionais;
+
+DROP TRIGGER eixo_search ON eixo;
+
+DROP TRIGGER tipo_servico_search ON tipo_servico;
+
+DROP FUNCTION taxonomy_search_trigger();
+
+DROP TRIGGER tipo_atendimento_servico_search ON tipo_atendimento_servico;
+
+DROP TRIGGER criterios_admissao_servico_search ON criterios_admissao_servico;
+
+DROP TRIGGER regionais_servico_search ON regionais_servico;
+
+DROP FUNCTION servico_relation_search_trigger();
+
+DROP TRIGGER servico_search ON servico;
+
+DROP FUNCTION servico_search_trigger();
+
+DROP FUNCTION servico_search_document(servico);
+
+ALTER TABLE
+  servico DROP COLUMN search_vector;
+
+DROP TEXT SEARCH CONFIGURATION public.pt_unaccent;
+
+DROP EXTENSION IF EXISTS unaccent;
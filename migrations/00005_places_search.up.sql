@@ -0,0 +1,134 @@
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- Portuguese stemming on top of unaccent, so "saude mental" matches
+-- "Saúde Mental" and "atendimentos" matches "atendimento".
+CREATE TEXT SEARCH CONFIGURATION public.pt_unaccent (COPY = pg_catalog.portuguese);
+
+ALTER TEXT SEARCH CONFIGURATION public.pt_unaccent
+ALTER MAPPING FOR hword, hword_part, word WITH unaccent, portuguese_stem;
+
+ALTER TABLE
+  servico
+ADD
+  COLUMN search_vector tsvector;
+
+CREATE INDEX servico_search_vector_idx ON servico USING gin (search_vector);
+
+-- The name weighs the most, then the taxonomy names, then the free text.
+CREATE FUNCTION servico_search_document(s servico)
+RETURNS tsvector
+LANGUAGE sql STABLE AS $$
+  SELECT
+    setweight(to_tsvector('public.pt_unaccent', coalesce(s.nome, '')), 'A') ||
+    setweight(to_tsvector('public.pt_unaccent', concat_ws(' ',
+      (SELECT ts.nome FROM public.tipo_servico ts WHERE ts.id = s.tipo_servico_id),
+      (SELECT e.nome FROM public.eixo e WHERE e.id = s.eixo_id),
+      (
+        SELECT string_agg(r.nome, ' ')
+        FROM public.regionais_servico rs
+        JOIN public.regionais r ON rs.regional_id = r.id
+        WHERE rs.servico_id = s.id
+      ),
+      (
+        SELECT string_agg(ca.nome, ' ')
+        FROM public.criterios_admissao_servico cas
+        JOIN public.criterios_admissao ca ON cas.criterio_admissao_id = ca.id
+        WHERE cas.servico_id = s.id
+      ),
+      (
+        SELECT string_agg(ta.nome, ' ')
+        FROM public.tipo_atendimento_servico tas
+        JOIN public.tipo_atendimento ta ON tas.tipo_atendimento_id = ta.id
+        WHERE tas.servico_id = s.id
+      )
+    )), 'B') ||
+    setweight(to_tsvector('public.pt_unaccent', concat_ws(' ', s.endereco, s.observacoes)), 'C')
+$$;
+
+CREATE FUNCTION servico_search_trigger()
+RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+  NEW.search_vector := servico_search_document(NEW);
+  RETURN NEW;
+END $$;
+
+CREATE TRIGGER servico_search
+BEFORE INSERT OR UPDATE ON servico
+FOR EACH ROW EXECUTE FUNCTION servico_search_trigger();
+
+-- Touching a servico row is enough to rebuild its search_vector through the
+-- servico_search trigger.
+CREATE FUNCTION servico_relation_search_trigger()
+RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    UPDATE servico SET search_vector = NULL WHERE id = OLD.servico_id;
+  ELSE
+    UPDATE servico SET search_vector = NULL WHERE id = NEW.servico_id;
+  END IF;
+
+  RETURN NULL;
+END $$;
+
+CREATE TRIGGER regionais_servico_search
+AFTER INSERT OR DELETE ON regionais_servico
+FOR EACH ROW EXECUTE FUNCTION servico_relation_search_trigger();
+
+CREATE TRIGGER criterios_admissao_servico_search
+AFTER INSERT OR DELETE ON criterios_admissao_servico
+FOR EACH ROW EXECUTE FUNCTION servico_relation_search_trigger();
+
+CREATE TRIGGER tipo_atendimento_servico_search
+AFTER INSERT OR DELETE ON tipo_atendimento_servico
+FOR EACH ROW EXECUTE FUNCTION servico_relation_search_trigger();
+
+CREATE FUNCTION taxonomy_search_trigger()
+RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+  CASE TG_TABLE_NAME
+    WHEN 'tipo_servico' THEN
+      UPDATE servico SET search_vector = NULL WHERE tipo_servico_id = NEW.id;
+    WHEN 'eixo' THEN
+      UPDATE servico SET search_vector = NULL WHERE eixo_id = NEW.id;
+    WHEN 'regionais' THEN
+      UPDATE servico SET search_vector = NULL
+      WHERE id IN (SELECT servico_id FROM regionais_servico WHERE regional_id = NEW.id);
+    WHEN 'criterios_admissao' THEN
+      UPDATE servico SET search_vector = NULL
+      WHERE id IN (
+        SELECT servico_id FROM criterios_admissao_servico WHERE criterio_admissao_id = NEW.id
+      );
+    WHEN 'tipo_atendimento' THEN
+      UPDATE servico SET search_vector = NULL
+      WHERE id IN (
+        SELECT servico_id FROM tipo_atendimento_servico WHERE tipo_atendimento_id = NEW.id
+      );
+  END CASE;
+
+  RETURN NULL;
+END $$;
+
+CREATE TRIGGER tipo_servico_search
+AFTER UPDATE OF nome ON tipo_servico
+FOR EACH ROW EXECUTE FUNCTION taxonomy_search_trigger();
+
+CREATE TRIGGER eixo_search
+AFTER UPDATE OF nome ON eixo
+FOR EACH ROW EXECUTE FUNCTION taxonomy_search_trigger();
+
+CREATE TRIGGER regionais_search
+AFTER UPDATE OF nome ON regionais
+FOR EACH ROW EXECUTE FUNCTION taxonomy_search_trigger();
+
+CREATE TRIGGER criterios_admissao_search
+AFTER UPDATE OF nome ON criterios_admissao
+FOR EACH ROW EXECUTE FUNCTION taxonomy_search_trigger();
+
+CREATE TRIGGER tipo_atendimento_search
+AFTER UPDATE OF nome ON tipo_atendimento
+FOR EACH ROW EXECUTE FUNCTION taxonomy_search_trigger();
+
+UPDATE servico SET search_vector = NULL;
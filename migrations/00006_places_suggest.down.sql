@@ -0,0 +1,11 @@
+DROP INDEX regionais_nome_trgm_idx;
+
+DROP INDEX tipo_servico_nome_trgm_idx;
+
+DROP INDEX eixo_nome_trgm_idx;
+
+DROP INDEX servico_nome_trgm_idx;
+
+DROP FUNCTION f_unaccent(text);
+
+DROP EXTENSION IF EXISTS pg_trgm;
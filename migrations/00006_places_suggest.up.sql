@@ -0,0 +1,17 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- unaccent is only STABLE because its dictionary can change, which keeps it
+-- out of index expressions. The dictionary is pinned here instead.
+CREATE FUNCTION f_unaccent(text)
+RETURNS text
+LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT AS $$
+  SELECT public.unaccent('public.unaccent'::regdictionary, $1)
+$$;
+
+CREATE INDEX servico_nome_trgm_idx ON servico USING gin (f_unaccent(lower(nome)) gin_trgm_ops);
+
+CREATE INDEX eixo_nome_trgm_idx ON eixo USING gin (f_unaccent(lower(nome)) gin_trgm_ops);
+
+CREATE INDEX tipo_servico_nome_trgm_idx ON tipo_servico USING gin (f_unaccent(lower(nome)) gin_trgm_ops);
+
+CREATE INDEX regionais_nome_trgm_idx ON regionais USING gin (f_unaccent(lower(nome)) gin_trgm_ops);
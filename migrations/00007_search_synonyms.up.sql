@@ -0,0 +1,12 @@
+CREATE TABLE sinonimos (
+  id bigint NOT NULL GENERATED ALWAYS AS IDENTITY UNIQUE,
+  termos varchar [] NOT NULL,
+  PRIMARY KEY (id)
+);
+
+INSERT INTO
+  sinonimos (termos)
+VALUES
+  (ARRAY ['psicólogo', 'psicologia', 'saúde mental', 'CAPS', 'CERSAM']),
+  (ARRAY ['droga', 'álcool', 'dependência química', 'CERSAM AD', 'CAPS AD']),
+  (ARRAY ['criança', 'adolescente', 'infantojuvenil', 'CERSAMI', 'CAPSi']);
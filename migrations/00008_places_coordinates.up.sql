@@ -0,0 +1,94 @@
+ALTER TABLE
+  servico
+ADD
+  COLUMN latitude double precision CHECK (latitude BETWEEN -90 AND 90),
+ADD
+  COLUMN longitude double precision CHECK (longitude BETWEEN -180 AND 180),
+ADD
+  CONSTRAINT servico_coordinates_check CHECK ((latitude IS NULL) = (longitude IS NULL));
+
+-- haversine_km returns the great-circle distance between two points in
+-- kilometres, on a spherical Earth.
+CREATE FUNCTION haversine_km(
+  lat1 double precision,
+  lng1 double precision,
+  lat2 double precision,
+  lng2 double precision
+)
+RETURNS double precision
+LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT AS $$
+  SELECT 2 * 6371.0088 * asin(sqrt(
+    power(sin(radians(lat2 - lat1) / 2), 2) +
+    cos(radians(lat1)) * cos(radians(lat2)) * power(sin(radians(lng2 - lng1) / 2), 2)
+  ))
+$$;
+
+DROP FUNCTION get_servicos();
+
+-- get_servicos returns one row per place, in the column order scanned by
+-- places.Repository. Every relation is aggregated in its own subquery so the
+-- joins do not multiply each other.
+CREATE FUNCTION get_servicos()
+RETURNS TABLE (
+  servico_id bigint,
+  servico_nome text,
+  servico_endereco text,
+  servico_contato text,
+  servico_site text,
+  servico_observacoes text,
+  servico_maps_link text,
+  servico_maps_embed_link text,
+  criterios_admissao jsonb,
+  tipos_atendimento jsonb,
+  formas_encaminhamento jsonb,
+  tipo_servico jsonb,
+  eixo jsonb,
+  regionais jsonb,
+  latitude double precision,
+  longitude double precision
+)
+LANGUAGE sql STABLE AS $$
+  SELECT
+    s.id,
+    s.nome::text,
+    s.endereco::text,
+    coalesce(s.contato, '')::text,
+    coalesce(s.site, '')::text,
+    coalesce(s.observacoes, '')::text,
+    coalesce(s.maps_link, '')::text,
+    coalesce(s.google_maps_embed_link, '')::text,
+    coalesce((
+      SELECT jsonb_agg(jsonb_build_object('id', ca.id, 'name', ca.nome) ORDER BY ca.id)
+      FROM public.criterios_admissao_servico cas
+      JOIN public.criterios_admissao ca ON cas.criterio_admissao_id = ca.id
+      WHERE cas.servico_id = s.id
+    ), '[]'::jsonb),
+    coalesce((
+      SELECT jsonb_agg(jsonb_build_object('id', ta.id, 'name', ta.nome) ORDER BY ta.id)
+      FROM public.tipo_atendimento_servico tas
+      JOIN public.tipo_atendimento ta ON tas.tipo_atendimento_id = ta.id
+      WHERE tas.servico_id = s.id
+    ), '[]'::jsonb),
+    coalesce((
+      SELECT jsonb_agg(jsonb_build_object('id', fe.id, 'name', fe.nome) ORDER BY fe.id)
+      FROM public.forma_encaminhamento_servico fes
+      JOIN public.forma_encaminhamento fe ON fes.forma_encaminhamento_id = fe.id
+      WHERE fes.servico_id = s.id
+    ), '[]'::jsonb),
+    jsonb_build_object('id', ts.id, 'name', ts.nome),
+    jsonb_build_object('id', e.id, 'name', e.nome),
+    coalesce((
+      SELECT jsonb_agg(jsonb_build_object('id', r.id, 'name', r.nome) ORDER BY r.id)
+      FROM public.regionais_servico rs
+      JOIN public.regionais r ON rs.regional_id = r.id
+      WHERE rs.servico_id = s.id
+    ), '[]'::jsonb),
+    s.latitude,
+    s.longitude
+  FROM
+    public.servico s
+    LEFT JOIN public.tipo_servico ts ON s.tipo_servico_id = ts.id
+    LEFT JOIN public.eixo e ON s.eixo_id = e.id
+  ORDER BY
+    s.id
+$$;
@@ -0,0 +1,6 @@
+-- boundary holds the GeoJSON MultiPolygon of the regional. Points are matched
+-- against it by regionals.Repository, so PostGIS is not required.
+ALTER TABLE
+  regionais
+ADD
+  COLUMN boundary jsonb;
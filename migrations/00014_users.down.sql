@@ -0,0 +1,3 @@
+DROP TABLE refresh_token;
+
+DROP TABLE usuario;
@@ -0,0 +1,28 @@
+CREATE TABLE usuario (
+  id bigint NOT NULL GENERATED ALWAYS AS IDENTITY UNIQUE,
+  nome varchar(255) NOT NULL,
+  email varchar(255) NOT NULL,
+  -- bcrypt hash, see users.HashPassword.
+  senha_hash varchar(72) NOT NULL,
+  admin boolean NOT NULL DEFAULT false,
+  ativo boolean NOT NULL DEFAULT true,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX usuario_email_key ON usuario (lower(email));
+
+-- Refresh tokens are opaque random strings, only their SHA-256 is stored.
+-- A token is revoked by logout, when refreshed, which replaces it, and when
+-- the password of its user changes or the user is deactivated.
+CREATE TABLE refresh_token (
+  id bigint NOT NULL GENERATED ALWAYS AS IDENTITY UNIQUE,
+  usuario_id bigint NOT NULL REFERENCES usuario (id) ON DELETE CASCADE,
+  token_hash bytea NOT NULL UNIQUE,
+  expires_at timestamptz NOT NULL,
+  revoked_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (id)
+);
+
+CREATE INDEX refresh_token_usuario_id_idx ON refresh_token (usuario_id);
@@ -0,0 +1,15 @@
+ALTER TABLE
+  usuario
+ADD
+  COLUMN admin boolean NOT NULL DEFAULT false;
+
+UPDATE
+  usuario u
+SET
+  admin = true
+WHERE
+  EXISTS (
+    SELECT 1 FROM papel_usuario p WHERE p.usuario_id = u.id AND p.papel = 'admin'
+  );
+
+DROP TABLE papel_usuario;
@@ -0,0 +1,25 @@
+-- Roles replace the admin flag of 00014. Editors and reviewers may be scoped
+-- to a regional, see rbac.Role.Scopable.
+CREATE TABLE papel_usuario (
+  id bigint NOT NULL GENERATED ALWAYS AS IDENTITY UNIQUE,
+  usuario_id bigint NOT NULL REFERENCES usuario (id) ON DELETE CASCADE,
+  papel varchar(32) NOT NULL CHECK (papel IN ('admin', 'reviewer', 'editor')),
+  regional_id bigint REFERENCES regionais (id) ON DELETE CASCADE,
+  PRIMARY KEY (id),
+  CHECK (papel <> 'admin' OR regional_id IS NULL)
+);
+
+CREATE UNIQUE INDEX papel_usuario_key ON papel_usuario (usuario_id, papel, coalesce(regional_id, 0));
+
+INSERT INTO
+  papel_usuario (usuario_id, papel)
+SELECT
+  id,
+  'admin'
+FROM
+  usuario
+WHERE
+  admin;
+
+ALTER TABLE
+  usuario DROP COLUMN admin;
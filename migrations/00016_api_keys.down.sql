@@ -0,0 +1 @@
+DROP TABLE chave_api;
@@ -0,0 +1,16 @@
+-- API keys of partner systems. Keys are random strings, only their SHA-256
+-- is stored, with a prefix that identifies them in listings. Revoked keys are
+-- kept so that the request logs still point to them.
+CREATE TABLE chave_api (
+  id bigint NOT NULL GENERATED ALWAYS AS IDENTITY UNIQUE,
+  nome varchar(255) NOT NULL,
+  prefixo varchar(16) NOT NULL,
+  chave_hash bytea NOT NULL UNIQUE,
+  escopos varchar(32)[] NOT NULL CHECK (escopos <@ ARRAY['read', 'write:places']::varchar(32)[]),
+  -- Requests allowed per minute.
+  limite_por_minuto integer NOT NULL CHECK (limite_por_minuto > 0),
+  usuario_id bigint REFERENCES usuario (id) ON DELETE SET NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  revoked_at timestamptz,
+  PRIMARY KEY (id)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE
+  regionais DROP COLUMN boundary_updated_at;
@@ -0,0 +1,13 @@
+-- boundary_updated_at versions the boundary, so that regionals.Repository
+-- parses each boundary once and keeps it until it changes.
+ALTER TABLE
+  regionais
+ADD
+  COLUMN boundary_updated_at timestamptz;
+
+UPDATE
+  regionais
+SET
+  boundary_updated_at = now()
+WHERE
+  boundary IS NOT NULL;
@@ -0,0 +1 @@
+DROP TABLE alteracao_servico;
@@ -0,0 +1,20 @@
+-- Changes to places submitted by principals that may not review them, kept
+-- until a reviewer of their regionals approves or rejects them. dados holds
+-- the form of creations and updates. regional_ids are the regionals the
+-- change touches, which decide who may review it.
+CREATE TABLE alteracao_servico (
+  id bigint NOT NULL GENERATED ALWAYS AS IDENTITY UNIQUE,
+  servico_id bigint REFERENCES servico (id) ON DELETE SET NULL,
+  acao varchar(16) NOT NULL CHECK (acao IN ('create', 'update', 'delete')),
+  dados jsonb,
+  regional_ids bigint[] NOT NULL DEFAULT '{}',
+  status varchar(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+  autor varchar(255) NOT NULL,
+  revisor varchar(255),
+  motivo text NOT NULL DEFAULT '',
+  created_at timestamptz NOT NULL DEFAULT now(),
+  reviewed_at timestamptz,
+  PRIMARY KEY (id)
+);
+
+CREATE INDEX alteracao_servico_status_idx ON alteracao_servico (status);
@@ -1,8 +1,17 @@
 package ctx
 
-import "context"
+import (
+	"context"
 
-const keyRequestID key = "requestID"
+	"cuide/util/rbac"
+)
+
+const (
+	keyRequestID key = "requestID"
+	keySubject   key = "subject"
+	keyPrincipal key = "principal"
+	keyAPIKeyID  key = "apiKeyID"
+)
 
 type key string
 
@@ -14,4 +23,40 @@ func RequestID(ctx context.Context) string {
 
 func SetRequestID(ctx context.Context, requestID string) context.Context {
 	return context.WithValue(ctx, keyRequestID, requestID)
-}
\ No newline at end of file
+}
+
+func Subject(ctx context.Context) string {
+	subject, _ := ctx.Value(keySubject).(string)
+
+	return subject
+}
+
+// SetSubject stores the authenticated subject, the sub claim of the bearer
+// token, of the request.
+func SetSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, keySubject, subject)
+}
+
+// Principal returns the principal stored by the authorization middleware,
+// or nil when the request was not authorized.
+func Principal(ctx context.Context) *rbac.Principal {
+	principal, _ := ctx.Value(keyPrincipal).(*rbac.Principal)
+
+	return principal
+}
+
+func SetPrincipal(ctx context.Context, principal *rbac.Principal) context.Context {
+	return context.WithValue(ctx, keyPrincipal, principal)
+}
+
+// APIKeyID returns the ID of the API key the request was made with, or an
+// empty string.
+func APIKeyID(ctx context.Context) string {
+	apiKeyID, _ := ctx.Value(keyAPIKeyID).(string)
+
+	return apiKeyID
+}
+
+func SetAPIKeyID(ctx context.Context, apiKeyID string) context.Context {
+	return context.WithValue(ctx, keyAPIKeyID, apiKeyID)
+}
@@ -17,6 +17,8 @@ func CallTx(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) (err error)
 		if errRb := tx.Rollback(); errRb != nil {
 			return fmt.Errorf("error on rollback %v, original error %w", errRb, err)
 		}
+
+		return err
 	}
 
 	return tx.Commit()
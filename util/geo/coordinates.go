@@ -0,0 +1,28 @@
+package geo
+
+import (
+	"errors"
+	"strconv"
+)
+
+var (
+	ErrInvalidLatitude  = errors.New("latitude must be a number between -90 and 90")
+	ErrInvalidLongitude = errors.New("longitude must be a number between -180 and 180")
+)
+
+// ParseCoordinates reads a latitude and a longitude in decimal degrees,
+// checking that they are within range. The checks are written so that NaN,
+// which compares false with everything, is out of range.
+func ParseCoordinates(lat, lng string) (float64, float64, error) {
+	latitude, err := strconv.ParseFloat(lat, 64)
+	if err != nil || !(latitude >= -90 && latitude <= 90) {
+		return 0, 0, ErrInvalidLatitude
+	}
+
+	longitude, err := strconv.ParseFloat(lng, 64)
+	if err != nil || !(longitude >= -180 && longitude <= 180) {
+		return 0, 0, ErrInvalidLongitude
+	}
+
+	return latitude, longitude, nil
+}
@@ -0,0 +1,30 @@
+package geo
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseCoordinates(t *testing.T) {
+	tests := []struct {
+		lat, lng string
+		want     error
+	}{
+		{"-23.55", "-46.63", nil},
+		{"90", "180", nil},
+		{"-90.1", "0", ErrInvalidLatitude},
+		{"north", "0", ErrInvalidLatitude},
+		{"", "0", ErrInvalidLatitude},
+		{"0", "180.5", ErrInvalidLongitude},
+		{"0", "NaN", ErrInvalidLongitude},
+		{"NaN", "0", ErrInvalidLatitude},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lat+","+tt.lng, func(t *testing.T) {
+			if _, _, err := ParseCoordinates(tt.lat, tt.lng); !errors.Is(err, tt.want) {
+				t.Errorf("ParseCoordinates = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
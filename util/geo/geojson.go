@@ -0,0 +1,150 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrUnsupportedType = errors.New("geometry must be a Polygon or a MultiPolygon")
+
+// Position is a GeoJSON position, longitude first.
+type Position [2]float64
+
+// Ring is a closed line: its first and last positions are equal.
+type Ring []Position
+
+// Polygon is an outer ring followed by its holes.
+type Polygon []Ring
+
+// Boundary is an area made of one or more polygons, read from a GeoJSON
+// Polygon or MultiPolygon.
+type Boundary struct {
+	Polygons []Polygon
+}
+
+type geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geometry       `json:"geometry"`
+}
+
+// ParseBoundary reads a GeoJSON Polygon or MultiPolygon, bare or wrapped in
+// a Feature, and checks that its rings are closed and within range.
+func ParseBoundary(data []byte) (*Boundary, error) {
+	var g geometry
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, err
+	}
+	if g.Type == "Feature" {
+		if g.Geometry == nil {
+			return nil, ErrUnsupportedType
+		}
+		g = *g.Geometry
+	}
+
+	b := &Boundary{}
+	switch g.Type {
+	case "Polygon":
+		var p Polygon
+		if err := json.Unmarshal(g.Coordinates, &p); err != nil {
+			return nil, err
+		}
+		b.Polygons = []Polygon{p}
+	case "MultiPolygon":
+		if err := json.Unmarshal(g.Coordinates, &b.Polygons); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrUnsupportedType
+	}
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// MarshalJSON writes b as a GeoJSON MultiPolygon.
+func (b *Boundary) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type        string    `json:"type"`
+		Coordinates []Polygon `json:"coordinates"`
+	}{
+		Type:        "MultiPolygon",
+		Coordinates: b.Polygons,
+	})
+}
+
+func (b *Boundary) validate() error {
+	if len(b.Polygons) == 0 {
+		return errors.New("geometry has no polygons")
+	}
+
+	for i, p := range b.Polygons {
+		if len(p) == 0 {
+			return fmt.Errorf("polygon %d has no rings", i)
+		}
+
+		for j, ring := range p {
+			if len(ring) < 4 {
+				return fmt.Errorf("polygon %d ring %d must have at least 4 positions", i, j)
+			}
+			if ring[0] != ring[len(ring)-1] {
+				return fmt.Errorf("polygon %d ring %d is not closed", i, j)
+			}
+
+			for _, pos := range ring {
+				if pos[0] < -180 || pos[0] > 180 || pos[1] < -90 || pos[1] > 90 {
+					return fmt.Errorf("polygon %d ring %d has a position out of range", i, j)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Contains reports whether the point is inside b. Points inside a hole are
+// outside the polygon.
+func (b *Boundary) Contains(lat, lng float64) bool {
+	for _, p := range b.Polygons {
+		if p.contains(lng, lat) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p Polygon) contains(x, y float64) bool {
+	if !p[0].contains(x, y) {
+		return false
+	}
+
+	for _, hole := range p[1:] {
+		if hole.contains(x, y) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// contains casts a ray from the point towards +x and counts how many edges
+// it crosses: an odd count means the point is inside.
+func (r Ring) contains(x, y float64) bool {
+	inside := false
+
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		xi, yi := r[i][0], r[i][1]
+		xj, yj := r[j][0], r[j][1]
+
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
@@ -0,0 +1,80 @@
+package geo
+
+import (
+	"errors"
+	"testing"
+)
+
+// square is a 10 by 10 degree square with a 2 by 2 hole in its middle, and
+// a second 1 by 1 square away from it.
+const square = `{
+	"type": "Feature",
+	"geometry": {
+		"type": "MultiPolygon",
+		"coordinates": [
+			[
+				[[0, 0], [10, 0], [10, 10], [0, 10], [0, 0]],
+				[[4, 4], [6, 4], [6, 6], [4, 6], [4, 4]]
+			],
+			[
+				[[20, 20], [21, 20], [21, 21], [20, 21], [20, 20]]
+			]
+		]
+	}
+}`
+
+func TestBoundaryContains(t *testing.T) {
+	b, err := ParseBoundary([]byte(square))
+	if err != nil {
+		t.Fatalf("ParseBoundary: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		lat, lng float64
+		want     bool
+	}{
+		{"inside", 2, 3, true},
+		{"inside the hole", 5, 5, false},
+		{"inside the second polygon", 20.5, 20.5, true},
+		{"outside", 15, 15, false},
+		{"swapped coordinates", 3, 12, false},
+		{"west of the square", 5, -1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.Contains(tt.lat, tt.lng); got != tt.want {
+				t.Errorf("Contains(%v, %v) = %v, want %v", tt.lat, tt.lng, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBoundaryRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not json", `{`},
+		{"point", `{"type": "Point", "coordinates": [0, 0]}`},
+		{"feature without geometry", `{"type": "Feature"}`},
+		{"no polygons", `{"type": "MultiPolygon", "coordinates": []}`},
+		{"no rings", `{"type": "Polygon", "coordinates": []}`},
+		{"short ring", `{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [0, 0]]]}`},
+		{"open ring", `{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 1]]]}`},
+		{"out of range", `{"type": "Polygon", "coordinates": [[[0, 0], [181, 0], [1, 1], [0, 0]]]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseBoundary([]byte(tt.data)); err == nil {
+				t.Error("ParseBoundary = nil, want an error")
+			}
+		})
+	}
+
+	if _, err := ParseBoundary([]byte(`{"type": "LineString"}`)); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("ParseBoundary = %v, want %v", err, ErrUnsupportedType)
+	}
+}
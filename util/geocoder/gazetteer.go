@@ -0,0 +1,184 @@
+package geocoder
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const SourceGazetteer = "gazetteer"
+
+const (
+	confidenceCEP        = 0.9
+	confidenceStreetCity = 0.7
+	confidenceStreet     = 0.5
+)
+
+var cepRegex = regexp.MustCompile(`\b(\d{5})-?(\d{3})\b`)
+
+// streetTypes expands the abbreviations commonly typed in addresses so they
+// match the gazetteer, e.g. "R." and "Av.".
+var streetTypes = map[string]string{
+	"r":    "rua",
+	"av":   "avenida",
+	"al":   "alameda",
+	"pc":   "praca",
+	"pca":  "praca",
+	"tv":   "travessa",
+	"rod":  "rodovia",
+	"estr": "estrada",
+}
+
+// gazetteerColumns are the columns read from the CSV header, in any order.
+// Other columns are ignored.
+var gazetteerColumns = []string{"cep", "street", "city", "latitude", "longitude"}
+
+type gazetteerEntry struct {
+	street    string
+	city      string
+	latitude  float64
+	longitude float64
+}
+
+// Gazetteer geocodes addresses offline from a CSV of streets and CEPs with
+// the header cep,street,city,latitude,longitude. A CEP found in the address
+// wins; otherwise the longest street name contained in the address is used,
+// preferring entries whose city is also in the address.
+type Gazetteer struct {
+	byCEP   map[string]*gazetteerEntry
+	streets []*gazetteerEntry
+}
+
+func NewGazetteer(path string) (*Gazetteer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadGazetteer(f)
+}
+
+func ReadGazetteer(rd io.Reader) (*Gazetteer, error) {
+	r := csv.NewReader(rd)
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("gazetteer header: %w", err)
+	}
+
+	idx := make(map[string]int, len(header))
+	for i, h := range header {
+		idx[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, c := range gazetteerColumns {
+		if _, ok := idx[c]; !ok {
+			return nil, fmt.Errorf("gazetteer header: missing column %q", c)
+		}
+	}
+
+	g := &Gazetteer{
+		byCEP: make(map[string]*gazetteerEntry),
+	}
+
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		entry := &gazetteerEntry{
+			street: expandStreetType(normalize(record[idx["street"]])),
+			city:   normalize(record[idx["city"]]),
+		}
+		if entry.latitude, err = strconv.ParseFloat(record[idx["latitude"]], 64); err != nil {
+			return nil, fmt.Errorf("gazetteer line %d: latitude: %w", line, err)
+		}
+		if entry.longitude, err = strconv.ParseFloat(record[idx["longitude"]], 64); err != nil {
+			return nil, fmt.Errorf("gazetteer line %d: longitude: %w", line, err)
+		}
+
+		if cep := normalizeCEP(record[idx["cep"]]); cep != "" {
+			g.byCEP[cep] = entry
+		}
+		if entry.street != "" {
+			g.streets = append(g.streets, entry)
+		}
+	}
+
+	return g, nil
+}
+
+func (g *Gazetteer) Geocode(ctx context.Context, address string) (*Result, error) {
+	if m := cepRegex.FindStringSubmatch(address); m != nil {
+		if entry, ok := g.byCEP[m[1]+m[2]]; ok {
+			return entry.result(confidenceCEP), nil
+		}
+	}
+
+	addr := " " + expandStreetType(normalize(address)) + " "
+
+	var best *gazetteerEntry
+	var bestCity bool
+	for _, entry := range g.streets {
+		if !strings.Contains(addr, " "+entry.street+" ") {
+			continue
+		}
+
+		city := entry.city != "" && strings.Contains(addr, " "+entry.city+" ")
+		switch {
+		case best == nil,
+			city && !bestCity,
+			city == bestCity && len(entry.street) > len(best.street):
+			best, bestCity = entry, city
+		}
+	}
+
+	if best == nil {
+		return nil, ErrNotFound
+	}
+	if bestCity {
+		return best.result(confidenceStreetCity), nil
+	}
+
+	return best.result(confidenceStreet), nil
+}
+
+func (e *gazetteerEntry) result(confidence float64) *Result {
+	return &Result{
+		Latitude:   e.latitude,
+		Longitude:  e.longitude,
+		Confidence: confidence,
+		Source:     SourceGazetteer,
+	}
+}
+
+func normalizeCEP(s string) string {
+	if m := cepRegex.FindStringSubmatch(strings.TrimSpace(s)); m != nil {
+		return m[1] + m[2]
+	}
+
+	return ""
+}
+
+// expandStreetType replaces an abbreviated street type in the words of a
+// normalized address with the full one.
+func expandStreetType(s string) string {
+	words := strings.Split(s, " ")
+	for i, w := range words {
+		if full, ok := streetTypes[w]; ok {
+			words[i] = full
+		}
+	}
+
+	return strings.Join(words, " ")
+}
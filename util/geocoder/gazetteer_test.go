@@ -0,0 +1,76 @@
+package geocoder
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testGazetteer = `street,cep,city,latitude,longitude,extra
+Rua da Consolação,01301-000,São Paulo,-23.5489,-46.6514,x
+Rua Augusta,,São Paulo,-23.5560,-46.6580,x
+Rua Augusta,,Santos,-23.9600,-46.3300,x
+Avenida Paulista,,São Paulo,-23.5614,-46.6559,x
+`
+
+func TestGazetteerGeocode(t *testing.T) {
+	g, err := ReadGazetteer(strings.NewReader(testGazetteer))
+	if err != nil {
+		t.Fatalf("ReadGazetteer: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		address    string
+		lat        float64
+		confidence float64
+	}{
+		{"cep", "R. Qualquer, 100 - CEP 01301000", -23.5489, confidenceCEP},
+		{"street and city", "Rua Augusta, 500 - Santos/SP", -23.9600, confidenceStreetCity},
+		{"abbreviated street", "Av. Paulista, 1000", -23.5614, confidenceStreet},
+		{"accents", "RUA DA CONSOLACAO, 10, sao paulo", -23.5489, confidenceStreetCity},
+		{"unknown cep", "Av. Paulista, 1000 - 99999-999", -23.5614, confidenceStreet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := g.Geocode(context.Background(), tt.address)
+			if err != nil {
+				t.Fatalf("Geocode: %v", err)
+			}
+			if res.Latitude != tt.lat || res.Confidence != tt.confidence {
+				t.Errorf("Geocode = %v with %v, want %v with %v", res.Latitude, res.Confidence, tt.lat, tt.confidence)
+			}
+			if res.Source != SourceGazetteer {
+				t.Errorf("Source = %q, want %q", res.Source, SourceGazetteer)
+			}
+		})
+	}
+
+	// Streets match whole words only, "Rua Augusta" is not in "Rua
+	// Augustinha".
+	if _, err := g.Geocode(context.Background(), "Rua Augustinha, 1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Geocode = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestReadGazetteerRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+	}{
+		{"empty", ""},
+		{"missing column", "cep,street,city,latitude\n"},
+		{"bad latitude", "cep,street,city,latitude,longitude\n,Rua A,,north,0\n"},
+		{"bad longitude", "cep,street,city,latitude,longitude\n,Rua A,,0,west\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadGazetteer(strings.NewReader(tt.csv)); err == nil {
+				t.Error("ReadGazetteer = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package geocoder
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var ErrNotFound = errors.New("address not found")
+
+// Geocoder turns a free-text address into coordinates.
+type Geocoder interface {
+	Geocode(ctx context.Context, address string) (*Result, error)
+}
+
+type Result struct {
+	Latitude  float64
+	Longitude float64
+	// Confidence goes from 0 to 1, 1 being an exact match of the address.
+	Confidence float64
+	// Source names the implementation that produced the result.
+	Source string
+}
+
+var accentReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// normalize lowercases s, strips Portuguese accents and collapses anything
+// that is not a letter or a digit into single spaces.
+func normalize(s string) string {
+	s = accentReplacer.Replace(strings.ToLower(s))
+
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	}), " ")
+}
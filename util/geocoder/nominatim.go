@@ -0,0 +1,112 @@
+package geocoder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const SourceNominatim = "nominatim"
+
+// Nominatim geocodes addresses with the /search endpoint of a
+// Nominatim-compatible server.
+type Nominatim struct {
+	baseURL      string
+	userAgent    string
+	countryCodes string
+	client       *http.Client
+}
+
+// NewNominatim returns a Nominatim client for the server at baseURL, e.g.
+// https://nominatim.openstreetmap.org. countryCodes is a comma separated
+// list of ISO 3166-1 alpha-2 codes limiting the search, or empty for none.
+func NewNominatim(client *http.Client, baseURL, userAgent, countryCodes string) *Nominatim {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &Nominatim{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		userAgent:    userAgent,
+		countryCodes: countryCodes,
+		client:       client,
+	}
+}
+
+type nominatimPlace struct {
+	Lat       string `json:"lat"`
+	Lon       string `json:"lon"`
+	PlaceRank int    `json:"place_rank"`
+}
+
+func (n *Nominatim) Geocode(ctx context.Context, address string) (*Result, error) {
+	q := url.Values{}
+	q.Set("q", address)
+	q.Set("format", "jsonv2")
+	q.Set("limit", "1")
+	if n.countryCodes != "" {
+		q.Set("countrycodes", n.countryCodes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.baseURL+"/search?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if n.userAgent != "" {
+		req.Header.Set("User-Agent", n.userAgent)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("nominatim: unexpected status %s", resp.Status)
+	}
+
+	var places []nominatimPlace
+	if err := json.NewDecoder(resp.Body).Decode(&places); err != nil {
+		return nil, fmt.Errorf("nominatim: %w", err)
+	}
+	if len(places) == 0 {
+		return nil, ErrNotFound
+	}
+
+	lat, err := strconv.ParseFloat(places[0].Lat, 64)
+	if err != nil {
+		return nil, fmt.Errorf("nominatim: latitude: %w", err)
+	}
+	lng, err := strconv.ParseFloat(places[0].Lon, 64)
+	if err != nil {
+		return nil, fmt.Errorf("nominatim: longitude: %w", err)
+	}
+
+	return &Result{
+		Latitude:   lat,
+		Longitude:  lng,
+		Confidence: rankConfidence(places[0].PlaceRank),
+		Source:     SourceNominatim,
+	}, nil
+}
+
+// rankConfidence maps a Nominatim place_rank, which grows from countries
+// (4) to buildings (30), to a confidence.
+func rankConfidence(rank int) float64 {
+	switch {
+	case rank >= 30:
+		return 0.9
+	case rank >= 26:
+		return 0.7
+	case rank >= 17:
+		return 0.4
+	default:
+		return 0.2
+	}
+}
@@ -0,0 +1,97 @@
+package geocoder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNominatimGeocode(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		want      *Result
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			"building",
+			http.StatusOK,
+			`[{"lat": "-19.9245", "lon": "-43.9352", "place_rank": 30}]`,
+			&Result{Latitude: -19.9245, Longitude: -43.9352, Confidence: 0.9, Source: SourceNominatim},
+			false,
+			nil,
+		},
+		{
+			"street",
+			http.StatusOK,
+			`[{"lat": "-19.9", "lon": "-43.9", "place_rank": 26}, {"lat": "0", "lon": "0", "place_rank": 30}]`,
+			&Result{Latitude: -19.9, Longitude: -43.9, Confidence: 0.7, Source: SourceNominatim},
+			false,
+			nil,
+		},
+		{"no results", http.StatusOK, `[]`, nil, true, ErrNotFound},
+		{"server error", http.StatusInternalServerError, `{"error": "boom"}`, nil, true, nil},
+		{"rate limited", http.StatusTooManyRequests, ``, nil, true, nil},
+		{"malformed json", http.StatusOK, `[{"lat": `, nil, true, nil},
+		{"not a list", http.StatusOK, `{"lat": "1", "lon": "2"}`, nil, true, nil},
+		{"bad latitude", http.StatusOK, `[{"lat": "north", "lon": "-43.9"}]`, nil, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			n := NewNominatim(srv.Client(), srv.URL+"/", "cuide-test/1.0", "br")
+			res, err := n.Geocode(context.Background(), "Rua da Bahia, 1000")
+
+			if got == nil {
+				t.Fatal("no request reached the server")
+			}
+			if got.URL.Path != "/search" {
+				t.Errorf("path = %q, want /search", got.URL.Path)
+			}
+			q := got.URL.Query()
+			if q.Get("q") != "Rua da Bahia, 1000" || q.Get("format") != "jsonv2" || q.Get("limit") != "1" || q.Get("countrycodes") != "br" {
+				t.Errorf("query = %q", got.URL.RawQuery)
+			}
+			if ua := got.Header.Get("User-Agent"); ua != "cuide-test/1.0" {
+				t.Errorf("User-Agent = %q, want %q", ua, "cuide-test/1.0")
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Geocode = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("Geocode = %v, want %v", err, tt.wantErrIs)
+			}
+			if tt.want != nil && *res != *tt.want {
+				t.Errorf("Geocode = %+v, want %+v", res, tt.want)
+			}
+		})
+	}
+}
+
+func TestNominatimGeocodeCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	n := NewNominatim(srv.Client(), srv.URL, "", "")
+	if _, err := n.Geocode(ctx, "Rua da Bahia"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Geocode = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,367 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+
+	// MinSecretLen is the length of the shortest HS256 secret accepted, as
+	// long as the SHA-256 digest it keys.
+	MinSecretLen = 32
+)
+
+// placeholderSecrets are secrets found in examples, which anyone could use
+// to sign tokens.
+var placeholderSecrets = []string{
+	"change-me",
+	"changeme",
+	"secret",
+	"your-256-bit-secret",
+	"your-secret-key",
+}
+
+var (
+	ErrMalformed         = errors.New("jwt: malformed token")
+	ErrAlgorithm         = errors.New("jwt: unexpected algorithm")
+	ErrSignature         = errors.New("jwt: invalid signature")
+	ErrExpired           = errors.New("jwt: token is expired")
+	ErrNoExpiry          = errors.New("jwt: token has no expiry")
+	ErrNotYetValid       = errors.New("jwt: token is not valid yet")
+	ErrIssuer            = errors.New("jwt: unexpected issuer")
+	ErrAudience          = errors.New("jwt: unexpected audience")
+	ErrSubject           = errors.New("jwt: token has no subject")
+	ErrNoKey             = errors.New("jwt: no key configured")
+	ErrWeakSecret        = errors.New("jwt: secret is shorter than 32 bytes or a placeholder")
+	ErrInvalidPublicKey  = errors.New("jwt: public key is not an RSA key")
+	ErrInvalidPrivateKey = errors.New("jwt: private key is not an RSA key")
+)
+
+// Claims are the registered claims written by a Signer and checked by a
+// Verifier.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+}
+
+// Audience is the aud claim, which is either a string or an array of them.
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = Audience{s}
+		return nil
+	}
+
+	var ss []string
+	if err := json.Unmarshal(data, &ss); err != nil {
+		return err
+	}
+	*a = ss
+
+	return nil
+}
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+
+	return json.Marshal([]string(a))
+}
+
+func (a Audience) contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+
+	return false
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// Verifier checks the signature and registered claims of compact JWS tokens
+// signed with HS256, RS256 or both.
+type Verifier struct {
+	secret    []byte
+	publicKey *rsa.PublicKey
+	issuer    string
+	audience  string
+	leeway    time.Duration
+	now       func() time.Time
+}
+
+// Options configure a Verifier. At least one of Secret and PublicKey is
+// required. Issuer and Audience are only checked when set.
+type Options struct {
+	// Secret verifies HS256 tokens.
+	Secret []byte
+	// PublicKey verifies RS256 tokens.
+	PublicKey *rsa.PublicKey
+	Issuer    string
+	Audience  string
+	// Leeway tolerates clock skew when checking exp and nbf.
+	Leeway time.Duration
+}
+
+func NewVerifier(o Options) (*Verifier, error) {
+	if len(o.Secret) == 0 && o.PublicKey == nil {
+		return nil, ErrNoKey
+	}
+	if err := checkSecret(o.Secret); err != nil {
+		return nil, err
+	}
+
+	return &Verifier{
+		secret:    o.Secret,
+		publicKey: o.PublicKey,
+		issuer:    o.Issuer,
+		audience:  o.Audience,
+		leeway:    o.Leeway,
+		now:       time.Now,
+	}, nil
+}
+
+// ReadPublicKey reads a PEM encoded RSA public key, either PKIX or PKCS #1,
+// from the file at path.
+func ReadPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("jwt: no PEM block in %s", path)
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, ErrInvalidPublicKey
+		}
+		return rsaKey, nil
+	}
+}
+
+// Verify returns the claims of token when its signature is valid for one of
+// the configured keys and its claims are currently valid. Tokens without an
+// exp claim, which would never expire, are rejected. The algorithm of the
+// header must match a configured key, so that an RS256 public key is never
+// used as an HS256 secret.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, ErrMalformed
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	signed := parts[0] + "." + parts[1]
+	switch {
+	case h.Alg == AlgHS256 && len(v.secret) > 0:
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return nil, ErrSignature
+		}
+	case h.Alg == AlgRS256 && v.publicKey != nil:
+		digest := sha256.Sum256([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(v.publicKey, crypto.SHA256, digest[:], signature); err != nil {
+			return nil, ErrSignature
+		}
+	default:
+		return nil, ErrAlgorithm
+	}
+
+	var c Claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, ErrMalformed
+	}
+
+	if err := v.validate(&c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (v *Verifier) validate(c *Claims) error {
+	now := v.now()
+
+	if c.ExpiresAt == 0 {
+		return ErrNoExpiry
+	}
+	if now.After(time.Unix(c.ExpiresAt, 0).Add(v.leeway)) {
+		return ErrExpired
+	}
+	if c.NotBefore != 0 && now.Before(time.Unix(c.NotBefore, 0).Add(-v.leeway)) {
+		return ErrNotYetValid
+	}
+	if v.issuer != "" && c.Issuer != v.issuer {
+		return ErrIssuer
+	}
+	if v.audience != "" && !c.Audience.contains(v.audience) {
+		return ErrAudience
+	}
+	if c.Subject == "" {
+		return ErrSubject
+	}
+
+	return nil
+}
+
+// checkSecret rejects the HS256 secrets that could be guessed: short ones
+// and placeholders. An empty secret disables HS256 and is left alone.
+func checkSecret(secret []byte) error {
+	if len(secret) == 0 {
+		return nil
+	}
+	if len(secret) < MinSecretLen || slices.Contains(placeholderSecrets, strings.ToLower(string(secret))) {
+		return ErrWeakSecret
+	}
+
+	return nil
+}
+
+func decodeSegment(s string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// Signer issues compact JWS tokens, with RS256 when it has a private key and
+// HS256 otherwise.
+type Signer struct {
+	secret     []byte
+	privateKey *rsa.PrivateKey
+}
+
+func NewSigner(secret []byte, privateKey *rsa.PrivateKey) (*Signer, error) {
+	if len(secret) == 0 && privateKey == nil {
+		return nil, ErrNoKey
+	}
+	if err := checkSecret(secret); err != nil {
+		return nil, err
+	}
+
+	return &Signer{secret: secret, privateKey: privateKey}, nil
+}
+
+// ReadPrivateKey reads a PEM encoded RSA private key, either PKCS #8 or
+// PKCS #1, from the file at path.
+func ReadPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("jwt: no PEM block in %s", path)
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, ErrInvalidPrivateKey
+		}
+		return rsaKey, nil
+	}
+}
+
+// Sign returns the token holding c.
+func (s *Signer) Sign(c *Claims) (string, error) {
+	h := header{Alg: AlgHS256, Typ: "JWT"}
+	if s.privateKey != nil {
+		h.Alg = AlgRS256
+	}
+
+	hs, err := encodeSegment(h)
+	if err != nil {
+		return "", err
+	}
+	cs, err := encodeSegment(c)
+	if err != nil {
+		return "", err
+	}
+
+	signed := hs + "." + cs
+
+	var signature []byte
+	if s.privateKey != nil {
+		digest := sha256.Sum256([]byte(signed))
+		signature, err = rsa.SignPKCS1v15(rand.Reader, s.privateKey, crypto.SHA256, digest[:])
+		if err != nil {
+			return "", err
+		}
+	} else {
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func encodeSegment(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
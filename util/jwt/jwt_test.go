@@ -0,0 +1,234 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testNow    = time.Unix(1_700_000_000, 0)
+	testSecret = []byte("0123456789abcdef0123456789abcdef")
+)
+
+func testVerifier(t *testing.T, o Options) *Verifier {
+	t.Helper()
+
+	v, err := NewVerifier(o)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	v.now = func() time.Time { return testNow }
+
+	return v
+}
+
+func validClaims() *Claims {
+	return &Claims{
+		Subject:   "42",
+		Issuer:    "cuide",
+		Audience:  Audience{"cuide-api"},
+		ExpiresAt: testNow.Add(time.Hour).Unix(),
+		IssuedAt:  testNow.Unix(),
+	}
+}
+
+func sign(t *testing.T, s *Signer, c *Claims) string {
+	t.Helper()
+
+	token, err := s.Sign(c)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	return token
+}
+
+func TestVerifyHS256(t *testing.T) {
+	secret := testSecret
+	signer, err := NewSigner(secret, nil)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v := testVerifier(t, Options{Secret: secret, Issuer: "cuide", Audience: "cuide-api"})
+
+	claims, err := v.Verify(sign(t, signer, validClaims()))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.Subject != "42" {
+		t.Errorf("Subject = %q, want 42", claims.Subject)
+	}
+}
+
+func TestVerifyRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	signer, err := NewSigner(nil, key)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v := testVerifier(t, Options{PublicKey: &key.PublicKey})
+
+	if _, err := v.Verify(sign(t, signer, validClaims())); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+}
+
+func TestVerifyClaims(t *testing.T) {
+	signer, err := NewSigner(testSecret, nil)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v := testVerifier(t, Options{
+		Secret:   testSecret,
+		Issuer:   "cuide",
+		Audience: "cuide-api",
+		Leeway:   time.Minute,
+	})
+
+	tests := []struct {
+		name   string
+		modify func(c *Claims)
+		want   error
+	}{
+		{"valid", func(c *Claims) {}, nil},
+		{"no exp", func(c *Claims) { c.ExpiresAt = 0 }, ErrNoExpiry},
+		{"expired", func(c *Claims) { c.ExpiresAt = testNow.Add(-2 * time.Minute).Unix() }, ErrExpired},
+		{"expired within leeway", func(c *Claims) { c.ExpiresAt = testNow.Add(-30 * time.Second).Unix() }, nil},
+		{"not yet valid", func(c *Claims) { c.NotBefore = testNow.Add(2 * time.Minute).Unix() }, ErrNotYetValid},
+		{"other issuer", func(c *Claims) { c.Issuer = "other" }, ErrIssuer},
+		{"other audience", func(c *Claims) { c.Audience = Audience{"other"} }, ErrAudience},
+		{"one of the audiences", func(c *Claims) { c.Audience = Audience{"other", "cuide-api"} }, nil},
+		{"no subject", func(c *Claims) { c.Subject = "" }, ErrSubject},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validClaims()
+			tt.modify(c)
+
+			_, err := v.Verify(sign(t, signer, c))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsForgedTokens(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	v := testVerifier(t, Options{PublicKey: &key.PublicKey})
+
+	hs, err := encodeSegment(header{Alg: AlgHS256, Typ: "JWT"})
+	if err != nil {
+		t.Fatalf("encodeSegment: %v", err)
+	}
+	none, err := encodeSegment(header{Alg: "none", Typ: "JWT"})
+	if err != nil {
+		t.Fatalf("encodeSegment: %v", err)
+	}
+	cs, err := encodeSegment(validClaims())
+	if err != nil {
+		t.Fatalf("encodeSegment: %v", err)
+	}
+
+	// An HS256 token signed with the public key as the secret must not pass
+	// when only the public key is configured.
+	pub, err := NewSigner([]byte(key.PublicKey.N.String()), nil)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	otherSigner, err := NewSigner(nil, other)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"hs256 with rsa key", sign(t, pub, validClaims()), ErrAlgorithm},
+		{"alg none", none + "." + cs + ".", ErrAlgorithm},
+		{"unsigned hs256", hs + "." + cs + ".", ErrAlgorithm},
+		{"other rsa key", sign(t, otherSigner, validClaims()), ErrSignature},
+		{"two segments", hs + "." + cs, ErrMalformed},
+		{"bad signature encoding", hs + "." + cs + ".!", ErrMalformed},
+		{"bad header", "!." + cs + "." + base64.RawURLEncoding.EncodeToString([]byte("x")), ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(tt.token)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsTamperedClaims(t *testing.T) {
+	signer, err := NewSigner(testSecret, nil)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v := testVerifier(t, Options{Secret: testSecret})
+
+	parts := strings.Split(sign(t, signer, validClaims()), ".")
+	c := validClaims()
+	c.Subject = "1"
+	parts[1], err = encodeSegment(c)
+	if err != nil {
+		t.Fatalf("encodeSegment: %v", err)
+	}
+
+	if _, err := v.Verify(strings.Join(parts, ".")); !errors.Is(err, ErrSignature) {
+		t.Errorf("Verify = %v, want %v", err, ErrSignature)
+	}
+}
+
+func TestNewVerifierNeedsAKey(t *testing.T) {
+	if _, err := NewVerifier(Options{}); !errors.Is(err, ErrNoKey) {
+		t.Errorf("NewVerifier = %v, want %v", err, ErrNoKey)
+	}
+	if _, err := NewSigner(nil, nil); !errors.Is(err, ErrNoKey) {
+		t.Errorf("NewSigner = %v, want %v", err, ErrNoKey)
+	}
+}
+
+func TestWeakSecret(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{"short", "0123456789abcdef"},
+		{"placeholder", "change-me"},
+		{"placeholder in capitals", "SECRET"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSigner([]byte(tt.secret), nil); !errors.Is(err, ErrWeakSecret) {
+				t.Errorf("NewSigner = %v, want %v", err, ErrWeakSecret)
+			}
+			if _, err := NewVerifier(Options{Secret: []byte(tt.secret)}); !errors.Is(err, ErrWeakSecret) {
+				t.Errorf("NewVerifier = %v, want %v", err, ErrWeakSecret)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package maps
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNotMapsURL    = errors.New("not a Google Maps URL")
+	ErrNoCoordinates = errors.New("Google Maps URL has no coordinates")
+	ErrOutOfRange    = errors.New("coordinates out of range")
+)
+
+const number = `(-?\d{1,3}(?:\.\d+)?)`
+
+var (
+	// !3d<lat>!4d<lng> marks the pinned place in /maps/place/ links.
+	placeDataRegex = regexp.MustCompile(`!3d` + number + `!4d` + number)
+	// !2d<lng>!3d<lat> is the map centre in /maps/embed?pb= links.
+	embedDataRegex = regexp.MustCompile(`!2d` + number + `!3d` + number)
+	// @<lat>,<lng>,<zoom>z is the viewport centre.
+	viewportRegex = regexp.MustCompile(`@` + number + `,` + number)
+	// <lat>,<lng> as a whole value, e.g. ?q=-19.92,-43.94 or /maps/place/-19.92,-43.94.
+	pairRegex = regexp.MustCompile(`^\s*` + number + `\s*,\s*` + number + `\s*$`)
+)
+
+// pairQueryParams are the query parameters that may hold a "lat,lng" pair,
+// in order of preference.
+var pairQueryParams = []string{"q", "query", "destination", "ll", "center", "sll"}
+
+// IsMapsURL reports whether link is a Google Maps URL, including short
+// maps.app.goo.gl and goo.gl/maps links.
+func IsMapsURL(link string) bool {
+	_, err := parseMapsURL(link)
+	return err == nil
+}
+
+// ParseCoordinates extracts the latitude and longitude from a Google Maps
+// URL. It returns ErrNotMapsURL when link is not a Google Maps URL and
+// ErrNoCoordinates when it is one that carries no coordinates, like a short
+// link.
+//
+// Formats are tried from the most to the least precise: the pinned place
+// (!3d…!4d…), the embed centre (!2d…!3d…), a "lat,lng" query parameter or
+// path segment and finally the viewport centre (@lat,lng).
+func ParseCoordinates(link string) (lat, lng float64, err error) {
+	u, err := parseMapsURL(link)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	path, _ := url.PathUnescape(u.EscapedPath())
+
+	if m := placeDataRegex.FindStringSubmatch(path); m != nil {
+		return parsePair(m[1], m[2])
+	}
+
+	if m := embedDataRegex.FindStringSubmatch(u.Query().Get("pb")); m != nil {
+		return parsePair(m[2], m[1])
+	}
+
+	for _, param := range pairQueryParams {
+		if m := pairRegex.FindStringSubmatch(u.Query().Get(param)); m != nil {
+			return parsePair(m[1], m[2])
+		}
+	}
+
+	for _, segment := range strings.Split(path, "/") {
+		if m := pairRegex.FindStringSubmatch(strings.ReplaceAll(segment, "+", " ")); m != nil {
+			return parsePair(m[1], m[2])
+		}
+	}
+
+	if m := viewportRegex.FindStringSubmatch(path); m != nil {
+		return parsePair(m[1], m[2])
+	}
+
+	return 0, 0, ErrNoCoordinates
+}
+
+func parseMapsURL(link string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, ErrNotMapsURL
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	switch {
+	case host == "maps.app.goo.gl":
+		return u, nil
+	case host == "goo.gl":
+		if strings.HasPrefix(u.Path, "/maps") {
+			return u, nil
+		}
+	case strings.HasPrefix(host, "maps.google."):
+		if isGoogleTLD(strings.TrimPrefix(host, "maps.google.")) {
+			return u, nil
+		}
+	case strings.HasPrefix(host, "google."):
+		if isGoogleTLD(strings.TrimPrefix(host, "google.")) && strings.HasPrefix(u.Path, "/maps") {
+			return u, nil
+		}
+	}
+
+	return nil, ErrNotMapsURL
+}
+
+// isGoogleTLD reports whether tld looks like one of Google's country
+// domains, e.g. "com", "com.br" or "co.uk".
+func isGoogleTLD(tld string) bool {
+	parts := strings.Split(tld, ".")
+	if len(parts) > 2 {
+		return false
+	}
+
+	for _, p := range parts {
+		if len(p) < 2 || len(p) > 3 || strings.Trim(p, "abcdefghijklmnopqrstuvwxyz") != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func parsePair(latStr, lngStr string) (lat, lng float64, err error) {
+	if lat, err = strconv.ParseFloat(latStr, 64); err != nil {
+		return 0, 0, err
+	}
+	if lng, err = strconv.ParseFloat(lngStr, 64); err != nil {
+		return 0, 0, err
+	}
+
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return 0, 0, ErrOutOfRange
+	}
+
+	return lat, lng, nil
+}
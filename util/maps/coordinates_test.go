@@ -0,0 +1,120 @@
+package maps
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseCoordinates(t *testing.T) {
+	tests := []struct {
+		name     string
+		link     string
+		lat, lng float64
+	}{
+		{
+			"viewport",
+			"https://www.google.com/maps/@-19.9245,-43.9352,15z",
+			-19.9245, -43.9352,
+		},
+		{
+			"query",
+			"https://maps.google.com/?q=-19.9245,-43.9352",
+			-19.9245, -43.9352,
+		},
+		{
+			"query with spaces",
+			"https://www.google.com.br/maps?q=-19.9245,+-43.9352",
+			-19.9245, -43.9352,
+		},
+		{
+			"search api",
+			"https://www.google.com/maps/search/?api=1&query=-19.9245%2C-43.9352",
+			-19.9245, -43.9352,
+		},
+		{
+			"place data",
+			"https://www.google.com/maps/place/CAPS/@-19.9,-43.9,17z/data=!3m1!4b1!4m6!3m5!1s0x0:0x0!8m2!3d-19.9245!4d-43.9352",
+			-19.9245, -43.9352,
+		},
+		{
+			"embed centre",
+			"https://www.google.com/maps/embed?pb=!1m18!1m12!1m3!1d3751.2!2d-43.9352!3d-19.9245!2m3",
+			-19.9245, -43.9352,
+		},
+		{
+			"path pair",
+			"https://www.google.com/maps/place/-19.9245,-43.9352",
+			-19.9245, -43.9352,
+		},
+		{
+			"integers",
+			"http://maps.google.co.uk/maps?ll=51,0",
+			51, 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lat, lng, err := ParseCoordinates(tt.link)
+			if err != nil {
+				t.Fatalf("ParseCoordinates: %v", err)
+			}
+			if lat != tt.lat || lng != tt.lng {
+				t.Errorf("ParseCoordinates = %v, %v, want %v, %v", lat, lng, tt.lat, tt.lng)
+			}
+		})
+	}
+}
+
+func TestParseCoordinatesRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		link string
+		want error
+	}{
+		{"short link", "https://maps.app.goo.gl/AbCdEf123", ErrNoCoordinates},
+		{"old short link", "https://goo.gl/maps/AbCdEf123", ErrNoCoordinates},
+		{"place without coordinates", "https://www.google.com/maps/place/CAPS+Barreiro", ErrNoCoordinates},
+		{"query address", "https://maps.google.com/?q=Rua+da+Bahia,+1000", ErrNoCoordinates},
+		{"latitude out of range", "https://maps.google.com/?q=-91,-43.9", ErrOutOfRange},
+		{"longitude out of range", "https://www.google.com/maps/@-19.9,181,15z", ErrOutOfRange},
+		{"other host", "https://www.openstreetmap.org/#map=15/-19.92/-43.93", ErrNotMapsURL},
+		{"google search", "https://www.google.com/search?q=-19.92,-43.93", ErrNotMapsURL},
+		{"lookalike host", "https://maps.google.evil.example/?q=-19.92,-43.93", ErrNotMapsURL},
+		{"goo.gl outside maps", "https://goo.gl/AbCdEf", ErrNotMapsURL},
+		{"no scheme", "maps.google.com/?q=-19.92,-43.93", ErrNotMapsURL},
+		{"javascript", "javascript:alert(1)//maps.google.com", ErrNotMapsURL},
+		{"malformed", "https://maps.google.com/%zz", ErrNotMapsURL},
+		{"empty", "", ErrNotMapsURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := ParseCoordinates(tt.link); !errors.Is(err, tt.want) {
+				t.Errorf("ParseCoordinates = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsMapsURL(t *testing.T) {
+	tests := []struct {
+		link string
+		want bool
+	}{
+		{"https://maps.app.goo.gl/AbCdEf123", true},
+		{" https://www.google.com.br/maps/place/CAPS ", true},
+		{"https://maps.google.com/", true},
+		{"https://google.com/", false},
+		{"https://maps.google.toolong/", false},
+		{"ftp://maps.google.com/", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			if got := IsMapsURL(tt.link); got != tt.want {
+				t.Errorf("IsMapsURL = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package maps
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// iframeSrcRegex matches the src attribute of an <iframe>, which must follow
+// a space so that attributes like data-src are skipped.
+var iframeSrcRegex = regexp.MustCompile(`(?is)<iframe\b[^>]*?\ssrc\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+
+// EmbedSrc returns the src of the first <iframe> in s when s is an HTML
+// snippet like the one given by Google Maps' "Embed a map", or s itself
+// otherwise.
+func EmbedSrc(s string) string {
+	s = strings.TrimSpace(s)
+
+	m := iframeSrcRegex.FindStringSubmatch(s)
+	if m == nil {
+		return s
+	}
+
+	return strings.TrimSpace(html.UnescapeString(m[1] + m[2]))
+}
+
+// IsEmbedURL reports whether link can be used as the src of a Google Maps
+// iframe: either a /maps/embed URL or a /maps URL with output=embed.
+func IsEmbedURL(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "https" {
+		return false
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "maps.")
+	if !strings.HasPrefix(host, "google.") || !isGoogleTLD(strings.TrimPrefix(host, "google.")) {
+		return false
+	}
+
+	switch {
+	case u.Path == "/maps/embed", strings.HasPrefix(u.Path, "/maps/embed/"):
+		return true
+	case u.Path == "/maps" || u.Path == "/maps/":
+		return u.Query().Get("output") == "embed"
+	default:
+		return false
+	}
+}
+
+// EmbedURLForCoordinates returns a keyless Google Maps embed URL centred on
+// a point.
+func EmbedURLForCoordinates(lat, lng float64) string {
+	return embedURL(
+		strconv.FormatFloat(lat, 'f', -1, 64) + "," + strconv.FormatFloat(lng, 'f', -1, 64),
+	)
+}
+
+// EmbedURLForAddress returns a keyless Google Maps embed URL searching for
+// an address.
+func EmbedURLForAddress(address string) string {
+	return embedURL(strings.TrimSpace(address))
+}
+
+func embedURL(q string) string {
+	v := url.Values{}
+	v.Set("q", q)
+	v.Set("z", "16")
+	v.Set("output", "embed")
+
+	return "https://maps.google.com/maps?" + v.Encode()
+}
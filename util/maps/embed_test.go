@@ -0,0 +1,88 @@
+package maps
+
+import "testing"
+
+func TestEmbedSrc(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{
+			"snippet",
+			`<iframe src="https://www.google.com/maps/embed?pb=!1m18" width="600" height="450" style="border:0;" allowfullscreen="" loading="lazy"></iframe>`,
+			"https://www.google.com/maps/embed?pb=!1m18",
+		},
+		{
+			"escaped ampersands",
+			`<iframe width="600" src="https://maps.google.com/maps?q=CAPS&amp;z=16&amp;output=embed"></iframe>`,
+			"https://maps.google.com/maps?q=CAPS&z=16&output=embed",
+		},
+		{
+			"single quotes and capitals",
+			`<IFRAME SRC = 'https://www.google.com/maps/embed?pb=!1m2'></IFRAME>`,
+			"https://www.google.com/maps/embed?pb=!1m2",
+		},
+		{
+			"first iframe",
+			`<iframe src="https://a.example/1"></iframe><iframe src="https://a.example/2"></iframe>`,
+			"https://a.example/1",
+		},
+		{
+			"data-src is not src",
+			`<iframe data-src="https://a.example/lazy" src="https://a.example/real"></iframe>`,
+			"https://a.example/real",
+		},
+		{"url", "  https://www.google.com/maps/embed?pb=!1m18 ", "https://www.google.com/maps/embed?pb=!1m18"},
+		{"iframe without src", `<iframe width="600"></iframe>`, `<iframe width="600"></iframe>`},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EmbedSrc(tt.s); got != tt.want {
+				t.Errorf("EmbedSrc = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsEmbedURL(t *testing.T) {
+	tests := []struct {
+		link string
+		want bool
+	}{
+		{"https://www.google.com/maps/embed?pb=!1m18", true},
+		{"https://www.google.com.br/maps/embed/v1/place?q=CAPS", true},
+		{"https://maps.google.com/maps?q=CAPS&output=embed", true},
+		{"https://maps.google.com/maps?q=CAPS", false},
+		{"http://www.google.com/maps/embed?pb=!1m18", false},
+		{"https://www.google.com/maps/place/CAPS", false},
+		{"https://evil.example/maps/embed?pb=!1m18", false},
+		{"https://www.google.com.evil.example/maps/embed", false},
+		{"javascript:alert(1)", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			if got := IsEmbedURL(tt.link); got != tt.want {
+				t.Errorf("IsEmbedURL = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbedURLs(t *testing.T) {
+	if got, want := EmbedURLForCoordinates(-19.9245, -43.9352), "https://maps.google.com/maps?output=embed&q=-19.9245%2C-43.9352&z=16"; got != want {
+		t.Errorf("EmbedURLForCoordinates = %q, want %q", got, want)
+	}
+
+	// Addresses are query escaped, so they can not break out of the src.
+	got := EmbedURLForAddress(` Rua "A" & <B>, 10 `)
+	if want := "https://maps.google.com/maps?output=embed&q=Rua+%22A%22+%26+%3CB%3E%2C+10&z=16"; got != want {
+		t.Errorf("EmbedURLForAddress = %q, want %q", got, want)
+	}
+	if !IsEmbedURL(got) {
+		t.Errorf("IsEmbedURL(%q) = false, want true", got)
+	}
+}
@@ -0,0 +1,405 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	txUtil "cuide/util/db-tx"
+)
+
+const createTableQuery = `
+CREATE TABLE IF NOT EXISTS public.schema_migrations (
+	version bigint NOT NULL,
+	dirty boolean NOT NULL DEFAULT false,
+	applied_at timestamptz NOT NULL DEFAULT now(),
+	PRIMARY KEY (version)
+);`
+
+var (
+	ErrNotMigrated    = errors.New("database has no schema_migrations table, run migrate up")
+	ErrNoChange       = errors.New("no change")
+	ErrUnknownVersion = errors.New("unknown migration version")
+	ErrNoDownFile     = errors.New("migration has no down file")
+)
+
+type ErrDirty struct {
+	Version uint64
+}
+
+func (e ErrDirty) Error() string {
+	return fmt.Sprintf("database is dirty at version %d, fix it manually and run force", e.Version)
+}
+
+type ErrPending struct {
+	Versions []uint64
+}
+
+func (e ErrPending) Error() string {
+	return fmt.Sprintf("database schema is behind, pending migrations %v, run migrate up", e.Versions)
+}
+
+type Status struct {
+	Migration *Migration
+	Applied   bool
+	Dirty     bool
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	fsys       fs.FS
+	migrations Migrations
+}
+
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		fsys:       fsys,
+		migrations: migrations,
+	}, nil
+}
+
+// Check reports whether the database schema matches the known migrations,
+// without creating or changing anything.
+func (m *Migrator) Check(ctx context.Context) error {
+	var exists bool
+	err := m.db.QueryRowContext(
+		ctx,
+		"SELECT to_regclass('public.schema_migrations') IS NOT NULL;",
+	).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotMigrated
+	}
+
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		versions := make([]uint64, len(pending))
+		for i, mig := range pending {
+			versions[i] = mig.Version
+		}
+
+		return ErrPending{Versions: versions}
+	}
+
+	return nil
+}
+
+// Status lists every known migration alongside its state in schema_migrations.
+func (m *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*Status, len(m.migrations))
+	for i, mig := range m.migrations {
+		statuses[i] = &Status{Migration: mig}
+		if s, ok := applied[mig.Version]; ok {
+			statuses[i].Applied = !s.Dirty
+			statuses[i].Dirty = s.Dirty
+			statuses[i].AppliedAt = s.AppliedAt
+		}
+	}
+
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied yet, in ascending order.
+func (m *Migrator) Pending(ctx context.Context) (Migrations, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDirty(applied); err != nil {
+		return nil, err
+	}
+
+	pending := make(Migrations, 0)
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			pending = append(pending, mig)
+		}
+	}
+
+	return pending, nil
+}
+
+// Up applies the next n pending migrations, or all of them when n <= 0.
+func (m *Migrator) Up(ctx context.Context, n int) ([]uint64, error) {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) == 0 {
+		return nil, ErrNoChange
+	}
+	if n > 0 && n < len(pending) {
+		pending = pending[:n]
+	}
+
+	done := make([]uint64, 0, len(pending))
+	for _, mig := range pending {
+		if err := m.up(ctx, mig); err != nil {
+			return done, fmt.Errorf("migration %d_%s up: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig.Version)
+	}
+
+	return done, nil
+}
+
+// Down reverts the last n applied migrations, or all of them when n <= 0.
+func (m *Migrator) Down(ctx context.Context, n int) ([]uint64, error) {
+	applied, err := m.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(applied) == 0 {
+		return nil, ErrNoChange
+	}
+	if n > 0 && n < len(applied) {
+		applied = applied[:n]
+	}
+
+	done := make([]uint64, 0, len(applied))
+	for _, mig := range applied {
+		if err := m.down(ctx, mig); err != nil {
+			return done, fmt.Errorf("migration %d_%s down: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig.Version)
+	}
+
+	return done, nil
+}
+
+// Goto migrates up or down until version is the latest applied migration.
+// Version 0 reverts every migration.
+func (m *Migrator) Goto(ctx context.Context, version uint64) ([]uint64, error) {
+	if version != 0 && m.migrations.Find(version) == nil {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	applied, err := m.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	appliedVersions := make(map[uint64]bool, len(applied))
+	done := make([]uint64, 0)
+	for _, mig := range applied {
+		appliedVersions[mig.Version] = true
+		if mig.Version <= version {
+			continue
+		}
+
+		if err := m.down(ctx, mig); err != nil {
+			return done, fmt.Errorf("migration %d_%s down: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig.Version)
+	}
+
+	for _, mig := range m.migrations {
+		if mig.Version > version || appliedVersions[mig.Version] {
+			continue
+		}
+
+		if err := m.up(ctx, mig); err != nil {
+			return done, fmt.Errorf("migration %d_%s up: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig.Version)
+	}
+
+	if len(done) == 0 {
+		return nil, ErrNoChange
+	}
+
+	return done, nil
+}
+
+// Force records every migration up to version as applied and clean, and
+// forgets the ones above it, without running any SQL file.
+func (m *Migrator) Force(ctx context.Context, version uint64) error {
+	if version != 0 && m.migrations.Find(version) == nil {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	if _, err := m.db.ExecContext(ctx, createTableQuery); err != nil {
+		return err
+	}
+
+	return txUtil.CallTx(ctx, m.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(
+			ctx,
+			"DELETE FROM public.schema_migrations WHERE version > $1;",
+			version,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE public.schema_migrations SET dirty = false;")
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if mig.Version > version {
+				break
+			}
+
+			_, err := tx.ExecContext(
+				ctx,
+				`INSERT INTO public.schema_migrations (version) VALUES ($1)
+				ON CONFLICT (version) DO NOTHING;`,
+				mig.Version,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// up marks the version as dirty before running its file, so that a failure
+// outside the transaction (e.g. a lost connection) leaves a visible trace.
+func (m *Migrator) up(ctx context.Context, mig *Migration) error {
+	query, err := fs.ReadFile(m.fsys, mig.UpPath)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.db.ExecContext(
+		ctx,
+		`INSERT INTO public.schema_migrations (version, dirty) VALUES ($1, true)
+		ON CONFLICT (version) DO UPDATE SET dirty = true, applied_at = now();`,
+		mig.Version,
+	)
+	if err != nil {
+		return err
+	}
+
+	return txUtil.CallTx(ctx, m.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, string(query)); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(
+			ctx,
+			"UPDATE public.schema_migrations SET dirty = false, applied_at = now() WHERE version = $1;",
+			mig.Version,
+		)
+		return err
+	})
+}
+
+func (m *Migrator) down(ctx context.Context, mig *Migration) error {
+	if mig.DownPath == "" {
+		return ErrNoDownFile
+	}
+
+	query, err := fs.ReadFile(m.fsys, mig.DownPath)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.db.ExecContext(
+		ctx,
+		"UPDATE public.schema_migrations SET dirty = true WHERE version = $1;",
+		mig.Version,
+	)
+	if err != nil {
+		return err
+	}
+
+	return txUtil.CallTx(ctx, m.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, string(query)); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(
+			ctx,
+			"DELETE FROM public.schema_migrations WHERE version = $1;",
+			mig.Version,
+		)
+		return err
+	})
+}
+
+// appliedMigrations returns the applied migrations, newest first.
+func (m *Migrator) appliedMigrations(ctx context.Context) (Migrations, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDirty(applied); err != nil {
+		return nil, err
+	}
+
+	migrations := make(Migrations, 0, len(applied))
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[m.migrations[i].Version]; ok {
+			migrations = append(migrations, m.migrations[i])
+		}
+	}
+
+	return migrations, nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[uint64]*Status, error) {
+	if _, err := m.db.ExecContext(ctx, createTableQuery); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(
+		ctx,
+		"SELECT version, dirty, applied_at FROM public.schema_migrations ORDER BY version;",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[uint64]*Status)
+	for rows.Next() {
+		var (
+			version uint64
+			status  Status
+		)
+
+		if err := rows.Scan(&version, &status.Dirty, &status.AppliedAt); err != nil {
+			return nil, err
+		}
+
+		status.Applied = !status.Dirty
+		applied[version] = &status
+	}
+
+	return applied, rows.Err()
+}
+
+func checkDirty(applied map[uint64]*Status) error {
+	for version, s := range applied {
+		if s.Dirty {
+			return ErrDirty{Version: version}
+		}
+	}
+
+	return nil
+}
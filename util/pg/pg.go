@@ -0,0 +1,36 @@
+package pg
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrNameTaken is returned when another row already has the name.
+	ErrNameTaken = errors.New("name already taken")
+	// ErrInUse is returned when deleting a row that other rows still refer
+	// to.
+	ErrInUse = errors.New("in use")
+)
+
+const (
+	ForeignKeyViolation = "23503"
+	UniqueViolation     = "23505"
+)
+
+// ConstraintErr maps the violations of a unique name and of the foreign keys
+// referring to the row to ErrNameTaken and ErrInUse.
+func ConstraintErr(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case UniqueViolation:
+			return ErrNameTaken
+		case ForeignKeyViolation:
+			return ErrInUse
+		}
+	}
+
+	return err
+}
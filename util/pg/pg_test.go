@@ -0,0 +1,37 @@
+package pg
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestConstraintErr(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"unique", &pq.Error{Code: UniqueViolation}, ErrNameTaken},
+		{"foreign key", &pq.Error{Code: ForeignKeyViolation}, ErrInUse},
+		{"wrapped", fmt.Errorf("update: %w", &pq.Error{Code: UniqueViolation}), ErrNameTaken},
+		{"other code", &pq.Error{Code: "23502"}, nil},
+		{"not a pq error", sql.ErrConnDone, sql.ErrConnDone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ConstraintErr(tt.err)
+			want := tt.want
+			if want == nil {
+				want = tt.err
+			}
+			if !errors.Is(got, want) {
+				t.Errorf("ConstraintErr = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package query
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SortKey is one expression of an ORDER BY. Name identifies it in cursors
+// and must not change when Expr does, e.g. when its placeholders move.
+//
+// Keyset pagination compares rows with = and < or >, so Expr must never be
+// NULL and the last key of an Order must be unique, like an id.
+type SortKey struct {
+	Name string
+	Expr string
+	Desc bool
+	// Kind is the type of the values of Expr, which the values of decoded
+	// cursors must have.
+	Kind Kind
+}
+
+// Kind is the type of the values of a SortKey.
+type Kind int
+
+const (
+	// KindInteger values are 64-bit integers, like ids.
+	KindInteger Kind = iota
+	// KindFloat values are finite numbers, or the Infinity and -Infinity of
+	// NewCursor.
+	KindFloat
+	KindText
+	// KindTime values are RFC 3339 timestamps.
+	KindTime
+)
+
+// valid reports whether v, decoded from a cursor, has kind k.
+func (k Kind) valid(v any) bool {
+	switch k {
+	case KindInteger:
+		if t, ok := v.(json.Number); ok {
+			_, err := strconv.ParseInt(string(t), 10, 64)
+			return err == nil
+		}
+	case KindFloat:
+		switch t := v.(type) {
+		case json.Number:
+			// Out of range numbers fail with ±Inf, which only the strings
+			// below may stand for.
+			_, err := strconv.ParseFloat(string(t), 64)
+			return err == nil
+		case string:
+			return t == "Infinity" || t == "-Infinity"
+		}
+	case KindText:
+		_, ok := v.(string)
+		return ok
+	case KindTime:
+		if t, ok := v.(string); ok {
+			_, err := time.Parse(time.RFC3339Nano, t)
+			return err == nil
+		}
+	}
+
+	return false
+}
+
+type Order []SortKey
+
+// Clause returns the ORDER BY clause, without the keywords, in the given
+// direction.
+func (o Order) Clause(backward bool) string {
+	keys := make([]string, len(o))
+	for i, k := range o {
+		keys[i] = k.Expr
+		if k.Desc != backward {
+			keys[i] += " DESC"
+		}
+	}
+
+	return strings.Join(keys, ", ")
+}
+
+// Seek adds to b the condition keeping only the rows after the cursor in
+// this order, or before it when the cursor goes backward.
+func (o Order) Seek(b *Builder, c *Cursor) {
+	ors := make([]string, len(o))
+	for i, k := range o {
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, o[j].Expr+" = "+b.Arg(c.Values[j]))
+		}
+
+		op := " > "
+		if k.Desc != c.Backward {
+			op = " < "
+		}
+		ands = append(ands, k.Expr+op+b.Arg(c.Values[i]))
+
+		ors[i] = "(" + strings.Join(ands, " AND ") + ")"
+	}
+
+	b.And(strings.Join(ors, " OR "))
+}
+
+func (o Order) signature() string {
+	names := make([]string, len(o))
+	for i, k := range o {
+		names[i] = k.Name
+		if k.Desc {
+			names[i] = "-" + names[i]
+		}
+	}
+
+	return strings.Join(names, ",")
+}
+
+// Cursor points between two rows of an Order: Values are the sort keys of
+// the row next to it.
+type Cursor struct {
+	Order    string `json:"o"`
+	Values   []any  `json:"v"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+// NewCursor returns the cursor right after, or before when backward, the
+// row whose sort keys are values.
+func (o Order) NewCursor(values []any, backward bool) *Cursor {
+	v := make([]any, len(values))
+	for i, value := range values {
+		switch t := value.(type) {
+		case []byte:
+			// Text columns are scanned as bytes, which JSON would encode in
+			// base64.
+			value = string(t)
+		case float64:
+			// JSON has no infinity, Postgres reads it back from text.
+			if math.IsInf(t, 1) {
+				value = "Infinity"
+			} else if math.IsInf(t, -1) {
+				value = "-Infinity"
+			}
+		}
+		v[i] = value
+	}
+
+	return &Cursor{
+		Order:    o.signature(),
+		Values:   v,
+		Backward: backward,
+	}
+}
+
+// Encode returns c as an opaque URL-safe string.
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reads a cursor returned by Encode, checking that it was made
+// for the order o and that its values have the kinds of the sort keys, so
+// that a crafted cursor never reaches the database.
+func (o Order) DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	// Numbers are kept as json.Number, sent to Postgres as text, so ids and
+	// floats survive the round trip unchanged.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var c Cursor
+	if err := dec.Decode(&c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Order != o.signature() || len(c.Values) != len(o) {
+		return nil, ErrInvalidCursor
+	}
+	for i, k := range o {
+		if !k.Kind.valid(c.Values[i]) {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,117 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+var testOrder = Order{
+	{Name: "name", Expr: "s.nome", Kind: KindText},
+	{Name: "updated_at", Expr: "s.updated_at", Desc: true, Kind: KindTime},
+	{Name: "distance", Expr: "distance", Kind: KindFloat},
+	{Name: "id", Expr: "s.id", Kind: KindInteger},
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	updatedAt := time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC)
+	values := []any{[]byte("Caps"), updatedAt, math.Inf(1), uint64(9007199254740993)}
+
+	c, err := testOrder.DecodeCursor(testOrder.NewCursor(values, true).Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+
+	if !c.Backward {
+		t.Error("Backward = false, want true")
+	}
+	want := []any{"Caps", updatedAt.Format(time.RFC3339Nano), "Infinity", json.Number("9007199254740993")}
+	for i := range want {
+		if c.Values[i] != want[i] {
+			t.Errorf("Values[%d] = %#v, want %#v", i, c.Values[i], want[i])
+		}
+	}
+}
+
+func encode(c any) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	now := time.Now().Format(time.RFC3339Nano)
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!"},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("{"))},
+		{"other order", encode(Cursor{Order: "name,id", Values: []any{"a", 1}})},
+		{"reversed order", encode(Cursor{Order: "-name,updated_at,distance,id", Values: []any{"a", now, 1, 1}})},
+		{"missing values", encode(Cursor{Order: testOrder.signature(), Values: []any{"a", now, 1}})},
+		{"number for text", encode(Cursor{Order: testOrder.signature(), Values: []any{1, now, 1, 1}})},
+		{"text for time", encode(Cursor{Order: testOrder.signature(), Values: []any{"a", "yesterday", 1, 1}})},
+		{"text for number", encode(Cursor{Order: testOrder.signature(), Values: []any{"a", now, "1; DROP", 1}})},
+		{"null for number", encode(Cursor{Order: testOrder.signature(), Values: []any{"a", now, 1, nil}})},
+		{"infinity for integer", encode(Cursor{Order: testOrder.signature(), Values: []any{"a", now, 1, "Infinity"}})},
+		{"nan for integer", encode(Cursor{Order: testOrder.signature(), Values: []any{"a", now, 1, "NaN"}})},
+		{"nan for float", encode(Cursor{Order: testOrder.signature(), Values: []any{"a", now, "NaN", 1}})},
+		{"float for integer", encode(Cursor{Order: testOrder.signature(), Values: []any{"a", now, 1, 1.5}})},
+		{"integer overflow", base64.RawURLEncoding.EncodeToString([]byte(`{"o":"` + testOrder.signature() + `","v":["a","` + now + `",1,99999999999999999999]}`))},
+		{"float overflow", base64.RawURLEncoding.EncodeToString([]byte(`{"o":"` + testOrder.signature() + `","v":["a","` + now + `",1e400,1]}`))},
+		{"object for text", encode(Cursor{Order: testOrder.signature(), Values: []any{map[string]any{}, now, 1, 1}})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := testOrder.DecodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestSeek(t *testing.T) {
+	order := Order{
+		{Name: "name", Expr: "nome", Kind: KindText},
+		{Name: "id", Expr: "id", Desc: true, Kind: KindInteger},
+	}
+
+	tests := []struct {
+		name     string
+		backward bool
+		want     string
+	}{
+		{"forward", false, " WHERE ((nome > $1) OR (nome = $2 AND id < $3))"},
+		{"backward", true, " WHERE ((nome < $1) OR (nome = $2 AND id > $3))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New()
+			order.Seek(b, &Cursor{Values: []any{"a", json.Number("7")}, Backward: tt.backward})
+
+			if got := b.Where(); got != tt.want {
+				t.Errorf("Where = %q, want %q", got, tt.want)
+			}
+			if got := len(b.Args()); got != 3 {
+				t.Errorf("len(Args) = %d, want 3", got)
+			}
+		})
+	}
+}
+
+func TestClause(t *testing.T) {
+	order := Order{{Expr: "nome"}, {Expr: "id", Desc: true}}
+
+	if got, want := order.Clause(false), "nome, id DESC"; got != want {
+		t.Errorf("Clause(false) = %q, want %q", got, want)
+	}
+	if got, want := order.Clause(true), "nome DESC, id"; got != want {
+		t.Errorf("Clause(true) = %q, want %q", got, want)
+	}
+}
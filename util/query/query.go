@@ -0,0 +1,92 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Builder collects the conditions of a WHERE clause and the positional
+// arguments they reference.
+type Builder struct {
+	conditions []string
+	args       []any
+}
+
+func New() *Builder {
+	return &Builder{}
+}
+
+// And adds a condition joined with AND to the previous ones. Every `?` in
+// cond is replaced with the placeholder of the matching value in args.
+func (b *Builder) And(cond string, args ...any) *Builder {
+	var sb strings.Builder
+
+	i := 0
+	for _, c := range cond {
+		if c == '?' && i < len(args) {
+			sb.WriteString(b.Arg(args[i]))
+			i++
+			continue
+		}
+		sb.WriteRune(c)
+	}
+
+	b.conditions = append(b.conditions, "("+sb.String()+")")
+	return b
+}
+
+// Arg appends a positional argument and returns its placeholder, e.g. $3.
+func (b *Builder) Arg(v any) string {
+	b.args = append(b.args, v)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// Where returns the conditions as a WHERE clause, or an empty string when
+// there are none.
+func (b *Builder) Where() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE " + b.Expr()
+}
+
+// Expr returns the conditions as a single boolean expression, TRUE when
+// there are none, e.g. for a JOIN ... ON clause.
+func (b *Builder) Expr() string {
+	if len(b.conditions) == 0 {
+		return "TRUE"
+	}
+
+	return strings.Join(b.conditions, " AND ")
+}
+
+func (b *Builder) Args() []any {
+	return b.args
+}
+
+// Clone returns a copy that can receive more conditions or arguments
+// without changing b.
+func (b *Builder) Clone() *Builder {
+	return &Builder{
+		conditions: append([]string(nil), b.conditions...),
+		args:       append([]any(nil), b.args...),
+	}
+}
+
+// Next returns a builder without conditions for another clause of the same
+// statement. Its placeholders continue after the ones of b, so its Args are
+// the arguments of both.
+func (b *Builder) Next() *Builder {
+	return &Builder{
+		args: append([]any(nil), b.args...),
+	}
+}
+
+// Contains wraps s as a LIKE pattern matching any value that contains it,
+// escaping the LIKE wildcards.
+func Contains(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
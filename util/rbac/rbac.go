@@ -0,0 +1,154 @@
+package rbac
+
+import "slices"
+
+type Role string
+
+const (
+	// RoleAdmin manages the taxonomies and the users, and edits every place.
+	RoleAdmin Role = "admin"
+	// RoleReviewer approves or rejects the changes submitted to places.
+	RoleReviewer Role = "reviewer"
+	// RoleEditor edits places, usually scoped to the regional they
+	// coordinate. Their changes wait for a reviewer, unless they may also
+	// review them.
+	RoleEditor Role = "editor"
+)
+
+// Roles lists every role, in the order shown to clients.
+var Roles = []Role{RoleAdmin, RoleReviewer, RoleEditor}
+
+type Permission string
+
+const (
+	PermPlacesWrite     Permission = "places:write"
+	PermPlacesReview    Permission = "places:review"
+	PermTaxonomiesWrite Permission = "taxonomies:write"
+	PermUsersManage     Permission = "users:manage"
+	PermAPIKeysManage   Permission = "api-keys:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin:    {PermPlacesWrite, PermPlacesReview, PermTaxonomiesWrite, PermUsersManage, PermAPIKeysManage},
+	RoleReviewer: {PermPlacesReview},
+	RoleEditor:   {PermPlacesWrite},
+}
+
+// Scopable reports whether assignments of r may be restricted to a
+// regional. Admins manage resources that belong to no regional, so they
+// are always global.
+func (r Role) Scopable() bool {
+	return r == RoleReviewer || r == RoleEditor
+}
+
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Scope is granted to an API key. Keys have no roles, their scopes grant
+// permissions over everything.
+type Scope string
+
+const (
+	// ScopeRead allows the GET endpoints.
+	ScopeRead Scope = "read"
+	// ScopePlacesWrite allows submitting the creation, update and deletion
+	// of places, which wait for a reviewer.
+	ScopePlacesWrite Scope = "write:places"
+)
+
+// Scopes lists every scope.
+var Scopes = []Scope{ScopeRead, ScopePlacesWrite}
+
+var scopePermissions = map[Scope][]Permission{
+	ScopeRead:        {},
+	ScopePlacesWrite: {PermPlacesWrite},
+}
+
+func (s Scope) Valid() bool {
+	_, ok := scopePermissions[s]
+	return ok
+}
+
+// Assignment grants the permissions of Role, over the places of the
+// regional RegionalID or, when it is nil, over everything.
+type Assignment struct {
+	Role       Role
+	RegionalID *uint64
+}
+
+// Principal is whoever made a request, with the roles assigned to them or,
+// for an API key, its scopes.
+type Principal struct {
+	Subject     string
+	Assignments []Assignment
+	Scopes      []Scope
+}
+
+// HasScope reports whether p was granted scope.
+func (p *Principal) HasScope(scope Scope) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// Has reports whether p has perm in some scope. It gates the routes, the
+// handlers then check the regionals with HasIn.
+func (p *Principal) Has(perm Permission) bool {
+	if p.scoped(perm) {
+		return true
+	}
+
+	for _, a := range p.Assignments {
+		if slices.Contains(rolePermissions[a.Role], perm) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasIn reports whether p has perm over the regional, either globally or
+// through an assignment scoped to it.
+func (p *Principal) HasIn(perm Permission, regionalID uint64) bool {
+	if p.scoped(perm) {
+		return true
+	}
+
+	for _, a := range p.Assignments {
+		if !slices.Contains(rolePermissions[a.Role], perm) {
+			continue
+		}
+		if a.RegionalID == nil || *a.RegionalID == regionalID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasGlobal reports whether p has perm through an unscoped assignment or a
+// scope.
+func (p *Principal) HasGlobal(perm Permission) bool {
+	if p.scoped(perm) {
+		return true
+	}
+
+	for _, a := range p.Assignments {
+		if a.RegionalID == nil && slices.Contains(rolePermissions[a.Role], perm) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scoped reports whether one of the scopes of p grants perm.
+func (p *Principal) scoped(perm Permission) bool {
+	for _, s := range p.Scopes {
+		if slices.Contains(scopePermissions[s], perm) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,95 @@
+package rbac
+
+import "testing"
+
+func regional(id uint64) *uint64 {
+	return &id
+}
+
+func TestPrincipal(t *testing.T) {
+	admin := &Principal{Assignments: []Assignment{{Role: RoleAdmin}}}
+	editor := &Principal{Assignments: []Assignment{{Role: RoleEditor, RegionalID: regional(1)}}}
+	reviewer := &Principal{Assignments: []Assignment{
+		{Role: RoleEditor},
+		{Role: RoleReviewer, RegionalID: regional(2)},
+	}}
+	apiKey := &Principal{Scopes: []Scope{ScopeRead, ScopePlacesWrite}}
+	reader := &Principal{Scopes: []Scope{ScopeRead}}
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		perm      Permission
+		has       bool
+		in1, in2  bool
+		global    bool
+	}{
+		{"admin writes places", admin, PermPlacesWrite, true, true, true, true},
+		{"admin reviews places", admin, PermPlacesReview, true, true, true, true},
+		{"admin manages users", admin, PermUsersManage, true, true, true, true},
+		{"scoped editor writes places", editor, PermPlacesWrite, true, true, false, false},
+		{"scoped editor reviews nothing", editor, PermPlacesReview, false, false, false, false},
+		{"scoped editor manages no users", editor, PermUsersManage, false, false, false, false},
+		{"unscoped editor writes places", reviewer, PermPlacesWrite, true, true, true, true},
+		{"scoped reviewer reviews places", reviewer, PermPlacesReview, true, false, true, false},
+		{"api key writes places", apiKey, PermPlacesWrite, true, true, true, true},
+		{"api key reviews nothing", apiKey, PermPlacesReview, false, false, false, false},
+		{"api key manages no api keys", apiKey, PermAPIKeysManage, false, false, false, false},
+		{"read key writes nothing", reader, PermPlacesWrite, false, false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.principal.Has(tt.perm); got != tt.has {
+				t.Errorf("Has(%s) = %v, want %v", tt.perm, got, tt.has)
+			}
+			if got := tt.principal.HasIn(tt.perm, 1); got != tt.in1 {
+				t.Errorf("HasIn(%s, 1) = %v, want %v", tt.perm, got, tt.in1)
+			}
+			if got := tt.principal.HasIn(tt.perm, 2); got != tt.in2 {
+				t.Errorf("HasIn(%s, 2) = %v, want %v", tt.perm, got, tt.in2)
+			}
+			if got := tt.principal.HasGlobal(tt.perm); got != tt.global {
+				t.Errorf("HasGlobal(%s) = %v, want %v", tt.perm, got, tt.global)
+			}
+		})
+	}
+}
+
+func TestPrincipalHasScope(t *testing.T) {
+	p := &Principal{Scopes: []Scope{ScopeRead}}
+
+	if !p.HasScope(ScopeRead) {
+		t.Errorf("HasScope(%s) = false, want true", ScopeRead)
+	}
+	if p.HasScope(ScopePlacesWrite) {
+		t.Errorf("HasScope(%s) = true, want false", ScopePlacesWrite)
+	}
+}
+
+func TestRoles(t *testing.T) {
+	for _, r := range Roles {
+		if !r.Valid() {
+			t.Errorf("%s.Valid() = false, want true", r)
+		}
+	}
+	if Role("owner").Valid() {
+		t.Error("owner.Valid() = true, want false")
+	}
+
+	if RoleAdmin.Scopable() {
+		t.Error("admin.Scopable() = true, want false")
+	}
+	if !RoleEditor.Scopable() || !RoleReviewer.Scopable() {
+		t.Error("editor and reviewer must be scopable")
+	}
+
+	for _, s := range Scopes {
+		if !s.Valid() {
+			t.Errorf("%s.Valid() = false, want true", s)
+		}
+	}
+	if Scope("write:users").Valid() {
+		t.Error("write:users.Valid() = true, want false")
+	}
+}
@@ -4,13 +4,17 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+
+	"cuide/util/maps"
 )
 
 const (
 	alphaSpaceRegexString string = "^[a-zA-Z ]+$"
+	timeOfDayRegexString  string = "^(([01][0-9]|2[0-3]):[0-5][0-9]|24:00)$"
 )
 
 type ErrResponse struct {
@@ -31,6 +35,11 @@ func New() *validator.Validate {
 	})
 
 	validate.RegisterValidation("alpha_space", isAlphaSpace)
+	validate.RegisterValidation("google_maps_url", isGoogleMapsURL)
+	validate.RegisterValidation("google_maps_embed", isGoogleMapsEmbed)
+	validate.RegisterValidation("time_of_day", isTimeOfDay)
+	validate.RegisterValidation("time_after", isTimeAfter)
+	validate.RegisterValidation("max_bytes", isMaxBytes)
 
 	return validate
 }
@@ -47,8 +56,32 @@ func ToErrResponse(err error) *ErrResponse {
 				resp.Errors[i] = fmt.Sprintf("%s is a required field", err.Field())
 			case "max":
 				resp.Errors[i] = fmt.Sprintf("%s must be a maximum of %s in length", err.Field(), err.Param())
+			case "max_bytes":
+				resp.Errors[i] = fmt.Sprintf("%s must be a maximum of %s bytes in length", err.Field(), err.Param())
+			case "min":
+				resp.Errors[i] = fmt.Sprintf("%s must be a minimum of %s in length", err.Field(), err.Param())
+			case "email":
+				resp.Errors[i] = fmt.Sprintf("%s must be a valid email address", err.Field())
 			case "url":
 				resp.Errors[i] = fmt.Sprintf("%s must be a valid URL", err.Field())
+			case "google_maps_url":
+				resp.Errors[i] = fmt.Sprintf("%s must be a Google Maps link, e.g. https://www.google.com/maps/place/... or https://maps.app.goo.gl/...", err.Field())
+			case "google_maps_embed":
+				resp.Errors[i] = fmt.Sprintf("%s must be a Google Maps embed link or <iframe> snippet, e.g. https://www.google.com/maps/embed?pb=...", err.Field())
+			case "latitude", "longitude":
+				resp.Errors[i] = fmt.Sprintf("%s must be a valid %s", err.Field(), err.Tag())
+			case "oneof":
+				resp.Errors[i] = fmt.Sprintf("%s must be one of %s", err.Field(), strings.ReplaceAll(err.Param(), " ", ", "))
+			case "excluded_if":
+				resp.Errors[i] = fmt.Sprintf("%s must be empty when %s", err.Field(), strings.Replace(strings.ToLower(err.Param()), " ", " is ", 1))
+			case "required_with":
+				resp.Errors[i] = fmt.Sprintf("%s is required when %s is present", err.Field(), err.Param())
+			case "time_of_day":
+				resp.Errors[i] = fmt.Sprintf("%s must be a time between 00:00 and 24:00 in the HH:MM format", err.Field())
+			case "time_after":
+				resp.Errors[i] = fmt.Sprintf("%s must be later than %s", err.Field(), strings.ToLower(err.Param()))
+			case "unique":
+				resp.Errors[i] = fmt.Sprintf("%s must not repeat %s", err.Field(), strings.ToLower(err.Param()))
 			case "alpha_space":
 				resp.Errors[i] = fmt.Sprintf("%s can only contain alphabetic and space characters", err.Field())
 			case "datetime":
@@ -71,4 +104,40 @@ func ToErrResponse(err error) *ErrResponse {
 func isAlphaSpace(fl validator.FieldLevel) bool {
 	reg := regexp.MustCompile(alphaSpaceRegexString)
 	return reg.MatchString(fl.Field().String())
-}
\ No newline at end of file
+}
+
+func isGoogleMapsURL(fl validator.FieldLevel) bool {
+	return maps.IsMapsURL(fl.Field().String())
+}
+
+// isGoogleMapsEmbed also accepts an <iframe> snippet, checking its src.
+func isGoogleMapsEmbed(fl validator.FieldLevel) bool {
+	return maps.IsEmbedURL(maps.EmbedSrc(fl.Field().String()))
+}
+
+func isTimeOfDay(fl validator.FieldLevel) bool {
+	reg := regexp.MustCompile(timeOfDayRegexString)
+	return reg.MatchString(fl.Field().String())
+}
+
+// isTimeAfter compares two HH:MM fields, whose lexical order is their
+// chronological order.
+func isTimeAfter(fl validator.FieldLevel) bool {
+	other := fl.Parent().FieldByName(fl.Param())
+	if !other.IsValid() {
+		return false
+	}
+
+	return fl.Field().String() > other.String()
+}
+
+// isMaxBytes limits the length of a string in bytes rather than in
+// characters, e.g. for the 72 bytes bcrypt hashes.
+func isMaxBytes(fl validator.FieldLevel) bool {
+	max, err := strconv.Atoi(fl.Param())
+	if err != nil {
+		return false
+	}
+
+	return len(fl.Field().String()) <= max
+}